@@ -1,29 +1,111 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/netip"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/Nutan-Kum12/Gopherso/internal/auth"
+	"github.com/Nutan-Kum12/Gopherso/internal/mailer"
+	"github.com/Nutan-Kum12/Gopherso/internal/ratelimit"
 	"github.com/Nutan-Kum12/Gopherso/internal/store"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type application struct {
-	config config
-	store  store.Storage
+	config        config
+	store         store.Storage
+	authenticator auth.Authenticator
+	mailer        mailer.Mailer
+	logger        *slog.Logger
+	metrics       *metrics
+	tracer        trace.Tracer
+	rateLimiter   ratelimit.Store // Nil disables rate limiting
+
+	build        buildInfo
+	startedAt    time.Time
+	phase        *atomic.Int32                 // Lifecycle phase reported by readiness checks
+	healthChecks []healthCheck                 // Dependencies pinged by readiness checks
+	onStartup    []func(context.Context) error // Run once listening, e.g. migrations; not ready until they finish
+	onShutdown   []func(context.Context) error // Stop background workers once requests have drained
 }
 type config struct {
-	addr string
-	db   dbConfig
+	addr           string
+	frontendURL    string         // Base URL used in links sent to users
+	trustedProxies []netip.Prefix // Peers whose X-Forwarded-For and X-Real-IP headers are believed
+	db             dbConfig
+	auth           authConfig
+	mail           mailConfig
+	feed           feedConfig
+	comments       commentsConfig
+	shutdown       shutdownConfig
+	health         healthConfig
+	tracing        tracingConfig
+	log            logConfig
+	rateLimit      rateLimitConfig
 }
 type dbConfig struct {
-	uri         string // MongoDB connection URI
-	name        string // Database name
+	driver      string // "mongo", "postgres" or "memory"
+	uri         string // Connection URI for the driver
+	name        string // Database name (Mongo only; Postgres takes it from the URI)
 	maxPoolSize uint64 // Maximum number of connections in pool
-	minPoolSize uint64 // Minimum number of connections in pool
+	minPoolSize uint64 // Minimum number of connections in pool (idle connections kept on Postgres)
 	maxIdleTime string // Duration string, e.g. "15m" meaning 15 minutes
+	autoMigrate bool   // Apply pending migrations on startup
+}
+type feedConfig struct {
+	mode              string // "pull" builds feeds at read time, "fanout" pushes posts into timelines on write
+	workers           int    // Fan-out worker pool size
+	queueSize         int    // Posts waiting for fan-out before new ones are dropped
+	timelineSize      int    // Posts kept per user timeline
+	followerThreshold int    // Authors with more followers are pulled instead of fanned out
+}
+type shutdownConfig struct {
+	timeout    time.Duration // Deadline for draining requests and background workers
+	drainDelay time.Duration // Time spent reporting not ready before closing listeners
+}
+type logConfig struct {
+	level  string // "debug", "info", "warn" or "error"
+	format string // "json" or "text"
+}
+type tracingConfig struct {
+	exporter string // "none", "stdout" or "otlp-file"
+	file     string // Output path for the otlp-file exporter
+}
+type rateLimitConfig struct {
+	backend string           // "none", "memory" or "mongo" (shared by replicas, needs DB_DRIVER=mongo)
+	api     ratelimit.Policy // Every /v1 request but probes, per client IP
+	signup  ratelimit.Policy // POST /v1/users, per client IP
+	login   ratelimit.Policy // POST /v1/auth/login, per client IP
+	posts   ratelimit.Policy // POST /v1/posts, per user
+}
+type healthConfig struct {
+	timeout time.Duration // Deadline for each dependency check
+}
+type commentsConfig struct {
+	maxDepth int // Deepest reply level allowed; 0 disables replies
+}
+type authConfig struct {
+	secret     string        // HMAC key used to sign access tokens
+	issuer     string        // Token issuer and audience
+	exp        time.Duration // Access token lifetime
+	refreshExp time.Duration // Refresh token (session) lifetime
+}
+type mailConfig struct {
+	driver         string        // "log" or "file"
+	dir            string        // Output directory for the file driver
+	from           string        // Sender address
+	emailChangeExp time.Duration // Email change confirmation token lifetime
 }
 
 // chi.Mux implements http.Handler
@@ -35,30 +117,104 @@ func (app application) mount() http.Handler {
 	// mux.HandleFunc("GET /v1/health", app.healthCheckHandler) // Register routes (URL → handler)
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer) //this will log the start and end of each request with the elapsed processing time
+	r.Use(app.realIPMiddleware)
+	r.Use(app.tracingMiddleware)    // After RequestID so spans carry it
+	r.Use(app.metricsMiddleware)    // Outside Recoverer so panics count as 500s
+	r.Use(app.requestLogMiddleware) // After tracing so log lines carry the trace ID
+	r.Use(middleware.Recoverer)
 	// Set a timeout value on the request context (ctx), that will signal
 	// through ctx.Done() that the request has timed out and further
 	// processing should be stopped.
 	r.Use(middleware.Timeout(60 * time.Second))
+	r.NotFound(app.notFoundHandler)
+	r.MethodNotAllowed(app.methodNotAllowedHandler)
+	r.Method(http.MethodGet, "/metrics", app.metricsHandler()) // GET /metrics
+
 	r.Route("/v1", func(r chi.Router) {
-		r.Get("/health", app.healthCheckHandler)
+		// Health routes
+		r.Get("/health", app.readinessHandler)       // GET /v1/health (same as /health/ready)
+		r.Get("/health/live", app.livenessHandler)   // GET /v1/health/live
+		r.Get("/health/ready", app.readinessHandler) // GET /v1/health/ready
 
-		// User routes
-		r.Route("/users", func(r chi.Router) {
-			r.Post("/", app.createUserHandler)           // POST /v1/users
-			r.Get("/", app.getUserHandler)               // GET /v1/users?id={id}
-			r.Get("/posts", app.getUserWithPostsHandler) // GET /v1/users/posts?id={id}
-		})
+		// Everything but probes counts against the per-client limit
+		r.Group(func(r chi.Router) {
+			r.Use(app.rateLimit(app.config.rateLimit.api))
+
+			// Auth routes
+			r.Route("/auth", func(r chi.Router) {
+				r.With(app.rateLimit(app.config.rateLimit.login)).Post("/login", app.loginHandler) // POST /v1/auth/login
+				r.Post("/refresh", app.refreshTokenHandler)                                        // POST /v1/auth/refresh
+				r.Post("/logout", app.logoutHandler)                                               // POST /v1/auth/logout
+			})
+
+			// User routes
+			r.Route("/users", func(r chi.Router) {
+				r.With(app.rateLimit(app.config.rateLimit.signup)).Post("/", app.createUserHandler) // POST /v1/users
+				r.Get("/", app.getUserHandler)                                                      // GET /v1/users?id={id}
+				r.Get("/posts", app.getUserWithPostsHandler)                                        // GET /v1/users/posts?id={id}
+
+				r.Post("/email/confirm", app.confirmEmailChangeHandler) // POST /v1/users/email/confirm
+				r.Get("/{id}/followers", app.getFollowersHandler)       // GET /v1/users/{id}/followers
+				r.Get("/{id}/following", app.getFollowingHandler)       // GET /v1/users/{id}/following
+
+				// Authenticated user routes
+				r.Route("/me", func(r chi.Router) {
+					r.Use(app.authTokenMiddleware)
+					r.Get("/sessions", app.getSessionsHandler)           // GET /v1/users/me/sessions
+					r.Delete("/sessions/{id}", app.revokeSessionHandler) // DELETE /v1/users/me/sessions/{id}
+				})
+				r.Group(func(r chi.Router) {
+					r.Use(app.authTokenMiddleware)
+					r.Patch("/{id}", app.updateUserHandler)              // PATCH /v1/users/{id}
+					r.Delete("/{id}", app.deleteUserHandler)             // DELETE /v1/users/{id}
+					r.Post("/{id}/email", app.requestEmailChangeHandler) // POST /v1/users/{id}/email
+					r.Post("/{id}/follow", app.followUserHandler)        // POST /v1/users/{id}/follow
+					r.Delete("/{id}/follow", app.unfollowUserHandler)    // DELETE /v1/users/{id}/follow
+				})
+			})
+
+			// Feed routes
+			r.With(app.authTokenMiddleware).Get("/feed", app.getFeedHandler) // GET /v1/feed
+
+			// Search routes
+			r.With(app.optionalAuthTokenMiddleware).Get("/search/posts", app.searchPostsHandler) // GET /v1/search/posts?q={query}
+
+			// Tag routes
+			r.Route("/tags", func(r chi.Router) {
+				r.Get("/trending", app.getTrendingTagsHandler)                                        // GET /v1/tags/trending
+				r.With(app.optionalAuthTokenMiddleware).Get("/{tag}/posts", app.getPostsByTagHandler) // GET /v1/tags/{tag}/posts
+			})
+
+			// Post routes
+			r.Route("/posts", func(r chi.Router) {
+				r.Get("/{id}/comments", app.getCommentsHandler) // GET /v1/posts/{id}/comments
 
-		// Post routes
-		r.Route("/posts", func(r chi.Router) {
-			r.Post("/", app.createPostHandler)              // POST /v1/posts
-			r.Get("/", app.getPostsHandler)                 // GET /v1/posts (all posts)
-			r.Get("/single", app.getPostHandler)            // GET /v1/posts/single?id={id}
-			r.Get("/with-user", app.getPostWithUserHandler) // GET /v1/posts/with-user?id={id}
-			r.Get("/by-user", app.getPostsByUserHandler)    // GET /v1/posts/by-user?user_id={id}
+				// Post reads, with the viewer's reactions for signed-in callers
+				r.Group(func(r chi.Router) {
+					r.Use(app.optionalAuthTokenMiddleware)
+					r.Get("/", app.getPostsHandler)                 // GET /v1/posts (all posts)
+					r.Get("/single", app.getPostHandler)            // GET /v1/posts/single?id={id}
+					r.Get("/with-user", app.getPostWithUserHandler) // GET /v1/posts/with-user?id={id}
+					r.Get("/by-user", app.getPostsByUserHandler)    // GET /v1/posts/by-user?user_id={id}
+				})
+
+				// Authenticated post routes
+				r.Group(func(r chi.Router) {
+					r.Use(app.authTokenMiddleware)
+					r.With(app.rateLimit(app.config.rateLimit.posts)).Post("/", app.createPostHandler) // POST /v1/posts
+					r.Patch("/{id}", app.updatePostHandler)                                            // PATCH /v1/posts/{id}
+					r.Delete("/{id}", app.deletePostHandler)                                           // DELETE /v1/posts/{id}
+
+					// Comment routes
+					r.Post("/{id}/comments", app.createCommentHandler)               // POST /v1/posts/{id}/comments
+					r.Patch("/{id}/comments/{commentID}", app.updateCommentHandler)  // PATCH /v1/posts/{id}/comments/{commentID}
+					r.Delete("/{id}/comments/{commentID}", app.deleteCommentHandler) // DELETE /v1/posts/{id}/comments/{commentID}
+
+					// Reaction routes
+					r.Put("/{id}/reactions/{kind}", app.addReactionHandler)       // PUT /v1/posts/{id}/reactions/{kind}
+					r.Delete("/{id}/reactions/{kind}", app.removeReactionHandler) // DELETE /v1/posts/{id}/reactions/{kind}
+				})
+			})
 		})
 	})
 	return r
@@ -72,7 +228,84 @@ func (app application) run(mux http.Handler) error {
 		WriteTimeout: 15 * time.Second,
 		ReadTimeout:  10 * time.Second,
 		IdleTimeout:  time.Minute,
+		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelWarn),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app.logger.Info("Starting server", "addr", app.config.addr)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+
+	// Probes are served while the startup hooks run, reporting not ready
+	startupErr := make(chan error, 1)
+	go func() {
+		for _, start := range app.onStartup {
+			if err := start(ctx); err != nil {
+				startupErr <- err
+				return
+			}
+		}
+		startupErr <- nil
+	}()
+
+	// Whichever way run returns, background workers are drained
+	select {
+	case err := <-serveErr:
+		app.shutdown(srv)
+		return err
+	case err := <-startupErr:
+		if err != nil && ctx.Err() == nil {
+			app.shutdown(srv)
+			return fmt.Errorf("starting up: %w", err)
+		}
+		if err == nil {
+			app.phase.CompareAndSwap(phaseStarting, phaseReady)
+			app.logger.Info("Ready to serve requests")
+		}
+	}
+
+	select {
+	case err := <-serveErr:
+		app.shutdown(srv)
+		return err
+	case <-ctx.Done():
+	}
+	stop() // A second signal kills the process
+
+	app.shutdown(srv)
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	app.logger.Info("Server stopped")
+	return nil
+}
+
+// shutdown reports not ready, stops accepting connections and waits for
+// in-flight requests and then background workers to finish, all within
+// the shutdown timeout
+func (app application) shutdown(srv *http.Server) {
+	app.phase.Store(phaseDraining)
+	if delay := app.config.shutdown.drainDelay; delay > 0 {
+		// Let load balancers see the failing health check before the
+		// listener goes away
+		app.logger.Info("Shutting down, reporting not ready", "delay", delay.String())
+		time.Sleep(delay)
+	}
+
+	app.logger.Info("Draining requests", "timeout", app.config.shutdown.timeout.String())
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.shutdown.timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		app.logger.Warn("Requests still running at shutdown deadline", "error", err)
+		srv.Close()
+	}
+
+	for _, stop := range app.onShutdown {
+		if err := stop(ctx); err != nil {
+			app.logger.Warn("Background work unfinished at shutdown deadline", "error", err)
+		}
 	}
-	log.Printf("Starting server on %s", app.config.addr)
-	return srv.ListenAndServe()
 }
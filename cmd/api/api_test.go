@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/auth"
+	"github.com/Nutan-Kum12/Gopherso/internal/mailer"
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/Nutan-Kum12/Gopherso/internal/store/memory"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Run `go test ./cmd/api -update` to rewrite the golden files after an
+// intended change to a response
+var update = flag.Bool("update", false, "rewrite golden files with the actual responses")
+
+// testPassword is the password of every fixture user
+const testPassword = "password123"
+
+// testPasswordHash is hashed once since bcrypt dominates test time otherwise
+var testPasswordHash string
+
+// unknownID is a well-formed ID that no fixture has
+const unknownID = "000000000000000000000000"
+
+var (
+	idPattern = regexp.MustCompile(`^[0-9a-f]{24}$`)
+
+	// coveredRoutes collects the "METHOD pattern" of every route a route
+	// test hit, so TestMain can report routes from mount() without tests
+	coveredMu     sync.Mutex
+	coveredRoutes = map[string]bool{}
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	// Keep mailer and store logs out of the test output; the application
+	// logger of each test env discards its own
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	var err error
+	testPasswordHash, err = store.HashPassword(testPassword)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hashing test password:", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	if code == 0 && flag.Lookup("test.run").Value.String() == "" {
+		if missing := untestedRoutes(); len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "routes without tests:\n\t%s\n", strings.Join(missing, "\n\t"))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// testMailer records sent messages instead of delivering them
+type testMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *testMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// testEnv is an application backed by in-memory storage and seeded with a
+// small social graph:
+//
+//	alice, bob and carol; alice follows bob and carol follows alice
+//	bobPost (tags go, concurrency) and then alicePost (tags go, intro)
+//	comment by bob on alicePost and reply to it by alice
+//	bob reacted "like" to alicePost
+//	one session per user, and a pending email change of bob's
+//
+// Fixtures are referred to as {name} in request paths and bodies, and
+// their IDs are written back as {name} in golden files.
+type testEnv struct {
+	t       *testing.T
+	app     *application
+	handler http.Handler
+	mailer  *testMailer
+
+	vars   map[string]string // {name} -> value substituted into requests
+	names  map[string]string // fixture ID -> {name} in golden files
+	tokens map[string]string // user name -> access token
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	mail := &testMailer{}
+	metrics := newMetrics()
+	app := &application{
+		config: config{
+			frontendURL: "http://localhost:3000",
+			auth: authConfig{
+				secret:     "test-secret",
+				issuer:     "gopherso-test",
+				exp:        15 * time.Minute,
+				refreshExp: 30 * 24 * time.Hour,
+			},
+			mail: mailConfig{
+				from:           "Gopherso <no-reply@gopherso.test>",
+				emailChangeExp: 24 * time.Hour,
+			},
+			comments: commentsConfig{maxDepth: 1},
+		},
+		store:         store.Instrument(memory.NewStorage(), metrics.registry),
+		authenticator: auth.NewJWTAuthenticator("test-secret", "gopherso-test", 15*time.Minute),
+		mailer:        mail,
+		logger:        slog.New(slog.DiscardHandler),
+		metrics:       metrics,
+		tracer:        noop.NewTracerProvider().Tracer(tracerName),
+		build:         buildInfo{version: "test", commit: "test"},
+		startedAt:     time.Now(),
+		phase:         new(atomic.Int32),
+	}
+	app.phase.Store(phaseReady)
+
+	e := &testEnv{
+		t:       t,
+		app:     app,
+		handler: app.mount(),
+		mailer:  mail,
+		vars:    map[string]string{"unknown": unknownID},
+		names:   map[string]string{unknownID: "{unknown}"},
+		tokens:  map[string]string{},
+	}
+	e.seed()
+	return e
+}
+
+// seed creates the fixtures described on testEnv
+func (e *testEnv) seed() {
+	ctx := context.Background()
+	s := e.app.store
+
+	users := map[string]*store.User{}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user := &store.User{
+			Username: name,
+			Email:    name + "@example.com",
+			Password: testPasswordHash,
+		}
+		e.must(s.Users.Create(ctx, user))
+		users[name] = user
+		e.set(name, user.ID)
+
+		sessionID := store.NewID()
+		refreshToken, refreshHash, err := auth.NewRefreshToken(sessionID.String())
+		e.must(err)
+		e.must(s.Sessions.Create(ctx, &store.Session{
+			ID:        sessionID,
+			UserID:    user.ID,
+			TokenHash: refreshHash,
+			ExpiresAt: time.Now().Add(e.app.config.auth.refreshExp),
+		}))
+		e.set(name+"Session", sessionID)
+		e.vars[name+"Refresh"] = refreshToken
+
+		accessToken, _, err := e.app.authenticator.GenerateToken(user.ID.String(), sessionID.String())
+		e.must(err)
+		e.tokens[name] = accessToken
+	}
+
+	bio := "Gopher since 2012"
+	e.must(s.Users.Update(ctx, users["alice"].ID, store.UserUpdate{Bio: &bio}))
+
+	e.must(s.Follows.Follow(ctx, users["alice"].ID, users["bob"].ID))
+	e.must(s.Follows.Follow(ctx, users["carol"].ID, users["alice"].ID))
+
+	bobPost := &store.Post{
+		Title:   "Concurrency in Go",
+		Content: "Channels and goroutines make concurrent programs easy to reason about.",
+		UserID:  users["bob"].ID,
+		Tags:    []string{"go", "concurrency"},
+	}
+	e.must(s.Posts.Create(ctx, bobPost))
+	e.set("bobPost", bobPost.ID)
+
+	alicePost := &store.Post{
+		Title:   "Hello Gopherso",
+		Content: "My first post about gophers and their burrows.",
+		UserID:  users["alice"].ID,
+		Tags:    []string{"go", "intro"},
+	}
+	e.must(s.Posts.Create(ctx, alicePost))
+	e.set("alicePost", alicePost.ID)
+
+	comment := &store.Comment{PostID: alicePost.ID, UserID: users["bob"].ID, Content: "Welcome aboard!"}
+	e.must(s.Comments.Create(ctx, comment))
+	e.set("comment", comment.ID)
+
+	reply := &store.Comment{PostID: alicePost.ID, UserID: users["alice"].ID, ParentID: &comment.ID, Content: "Thanks, Bob!"}
+	e.must(s.Comments.Create(ctx, reply))
+	e.set("reply", reply.ID)
+
+	_, err := s.Reactions.Add(ctx, alicePost.ID, users["bob"].ID, "like")
+	e.must(err)
+
+	emailToken, emailTokenHash, err := auth.NewOpaqueToken()
+	e.must(err)
+	e.must(s.Users.RequestEmailChange(ctx, users["bob"].ID, "bob@example.net", emailTokenHash, time.Now().Add(time.Hour)))
+	e.vars["emailToken"] = emailToken
+}
+
+// set registers a fixture ID under name
+func (e *testEnv) set(name string, id store.ID) {
+	e.vars[name] = id.String()
+	e.names[id.String()] = "{" + name + "}"
+}
+
+func (e *testEnv) must(err error) {
+	e.t.Helper()
+	if err != nil {
+		e.t.Fatalf("seeding fixtures: %v", err)
+	}
+}
+
+// expand replaces {name} placeholders in s with fixture values
+func (e *testEnv) expand(s string) string {
+	for name, value := range e.vars {
+		s = strings.ReplaceAll(s, "{"+name+"}", value)
+	}
+	return s
+}
+
+// do serves a request through the router, authenticated as the fixture
+// user as unless it is empty
+func (e *testEnv) do(method, path, body, as string) *httptest.ResponseRecorder {
+	e.t.Helper()
+
+	req := httptest.NewRequest(method, e.expand(path), strings.NewReader(e.expand(body)))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if as != "" {
+		token, ok := e.tokens[as]
+		if !ok {
+			e.t.Fatalf("no fixture user %q", as)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rr := httptest.NewRecorder()
+	e.handler.ServeHTTP(rr, req)
+	return rr
+}
+
+// decode unmarshals a JSON response body into v
+func (e *testEnv) decode(rr *httptest.ResponseRecorder, v any) {
+	e.t.Helper()
+	if err := json.Unmarshal(rr.Body.Bytes(), v); err != nil {
+		e.t.Fatalf("decoding response %q: %v", rr.Body.String(), err)
+	}
+}
+
+// routeTest is one request against a freshly seeded testEnv. The response
+// body is compared with testdata/<test name>.golden.
+type routeTest struct {
+	name   string
+	method string
+	path   string // may contain {name} fixture placeholders
+	body   string // may contain {name} fixture placeholders
+	as     string // fixture user to authenticate as; empty for anonymous
+	status int
+}
+
+func runRouteTests(t *testing.T, tests []routeTest) {
+	t.Helper()
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.cover(tc.method, tc.path)
+
+			rr := env.do(tc.method, tc.path, tc.body, tc.as)
+			if rr.Code != tc.status {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.status, rr.Body.String())
+			}
+
+			if rr.Code == http.StatusNoContent {
+				if rr.Body.Len() != 0 {
+					t.Errorf("expected empty body, got %q", rr.Body.String())
+				}
+				return
+			}
+			if rr.Code == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 response without WWW-Authenticate challenge")
+			}
+
+			// Errors are always problem details
+			want := "application/json"
+			if rr.Code >= http.StatusBadRequest {
+				want = "application/problem+json"
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != want {
+				t.Errorf("Content-Type = %q, want %s", ct, want)
+			}
+
+			assertGolden(t, env.normalize(rr))
+		})
+	}
+}
+
+// cover records the route pattern mount() resolves method and path to
+func (e *testEnv) cover(method, path string) {
+	routes, ok := e.handler.(*chi.Mux)
+	if !ok {
+		return
+	}
+
+	path, _, _ = strings.Cut(e.expand(path), "?")
+	if pattern := routes.Find(chi.NewRouteContext(), method, path); pattern != "" {
+		coveredMu.Lock()
+		coveredRoutes[method+" "+strings.TrimSuffix(pattern, "/")] = true
+		coveredMu.Unlock()
+	}
+}
+
+// untestedRoutes lists the routes of mount() no route test hit
+func untestedRoutes() []string {
+	app := application{metrics: newMetrics()}
+	routes, ok := app.mount().(chi.Routes)
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	chi.Walk(routes, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(strings.ReplaceAll(route, "/*/", "/"), "/")
+		if !coveredRoutes[method+" "+route] {
+			missing = append(missing, method+" "+route)
+		}
+		return nil
+	})
+	sort.Strings(missing)
+	return missing
+}
+
+// normalize returns the response body with values that differ between
+// runs replaced by stable markers. JSON bodies are re-indented so golden
+// files diff well.
+func (e *testEnv) normalize(rr *httptest.ResponseRecorder) []byte {
+	e.t.Helper()
+
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(rr.Body.Bytes()))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return rr.Body.Bytes()
+	}
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(e.scrub("", v)); err != nil {
+		e.t.Fatalf("re-encoding response: %v", err)
+	}
+	return out.Bytes()
+}
+
+// scrub replaces fixture IDs with their {name}, other IDs with <id>,
+// timestamps with <time> and tokens, cursors and durations with markers
+func (e *testEnv) scrub(key string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if key == "errors" {
+			return v // Problem field errors are keyed by field, not holding its value
+		}
+		for k, val := range v {
+			v[k] = e.scrub(k, val)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = e.scrub(key, val)
+		}
+	case string:
+		switch key {
+		case "access_token", "refresh_token", "next_cursor", "uptime", "latency", "request_id":
+			return "<" + key + ">"
+		case "instance":
+			segments := strings.Split(v, "/")
+			for i, seg := range segments {
+				segments[i] = e.scrub("", seg).(string)
+			}
+			return strings.Join(segments, "/")
+		}
+		if name, ok := e.names[v]; ok {
+			return name
+		}
+		if idPattern.MatchString(v) {
+			return "<id>"
+		}
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return "<time>"
+		}
+	}
+	return v
+}
+
+// assertGolden compares got with the test's golden file, rewriting it
+// instead when -update is set
+func assertGolden(t *testing.T, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", filepath.FromSlash(t.Name())+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response does not match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/auth"
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+// LoginRequest represents the JSON payload for logging in
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// RefreshTokenRequest represents the JSON payload for refreshing or
+// revoking a session
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse represents the JSON response for an issued token pair
+type TokenResponse struct {
+	AccessToken           string    `json:"access_token"`
+	TokenType             string    `json:"token_type"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// loginHandler handles POST /v1/auth/login
+func (app *application) loginHandler(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	user, err := app.store.Users.VerifyCredentials(r.Context(), req.Email, req.Password)
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "user"))
+		return
+	}
+
+	// Start a new session for this device
+	sessionID := store.NewID()
+	refreshToken, refreshHash, err := auth.NewRefreshToken(sessionID.String())
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	session := &store.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		TokenHash: refreshHash,
+		UserAgent: r.UserAgent(),
+		IP:        clientIP(r),
+		ExpiresAt: time.Now().Add(app.config.auth.refreshExp),
+	}
+	if err := app.store.Sessions.Create(r.Context(), session); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeTokenResponse(w, r, session, refreshToken)
+}
+
+// refreshTokenHandler handles POST /v1/auth/refresh
+// The presented refresh token is rotated; replaying an old one revokes the session.
+func (app *application) refreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	sessionID, tokenHash, ok := parseRefreshToken(req.RefreshToken)
+	if !ok {
+		app.errorResponse(w, r, errInvalidRefreshToken)
+		return
+	}
+
+	newToken, newHash, err := auth.NewRefreshToken(sessionID.String())
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(app.config.auth.refreshExp)
+	session, err := app.store.Sessions.Rotate(r.Context(), sessionID, tokenHash, newHash, expiresAt)
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "session"))
+		return
+	}
+
+	app.writeTokenResponse(w, r, session, newToken)
+}
+
+// logoutHandler handles POST /v1/auth/logout
+func (app *application) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	sessionID, tokenHash, ok := parseRefreshToken(req.RefreshToken)
+	if !ok {
+		app.errorResponse(w, r, errInvalidRefreshToken)
+		return
+	}
+
+	session, err := app.store.Sessions.GetByID(r.Context(), sessionID)
+	if err != nil || !session.HasTokenHash(tokenHash) {
+		app.errorResponse(w, r, errInvalidRefreshToken)
+		return
+	}
+
+	// Revoking an already revoked session is not an error for logout
+	if session.RevokedAt == nil {
+		if err := app.store.Sessions.Revoke(r.Context(), session.ID, session.UserID); err != nil {
+			app.errorResponse(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTokenResponse issues an access token for session and writes it
+// together with the session's refresh token
+func (app *application) writeTokenResponse(w http.ResponseWriter, r *http.Request, session *store.Session, refreshToken string) {
+	accessToken, expiresAt, err := app.authenticator.GenerateToken(session.UserID.String(), session.ID.String())
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	response := TokenResponse{
+		AccessToken:           accessToken,
+		TokenType:             "Bearer",
+		ExpiresAt:             expiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// parseRefreshToken splits a refresh token into its session ID and hash
+func parseRefreshToken(token string) (store.ID, string, bool) {
+	sessionIDStr, tokenHash, err := auth.ParseRefreshToken(token)
+	if err != nil {
+		return "", "", false
+	}
+
+	sessionID, err := store.ParseID(sessionIDStr)
+	if err != nil {
+		return "", "", false
+	}
+
+	return sessionID, tokenHash, true
+}
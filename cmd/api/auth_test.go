@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+func TestAuthRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "login", method: http.MethodPost, path: "/v1/auth/login",
+			body: `{"email":"alice@example.com","password":"password123"}`, status: http.StatusOK},
+		{name: "login email is case-insensitive", method: http.MethodPost, path: "/v1/auth/login",
+			body: `{"email":"Alice@Example.com","password":"password123"}`, status: http.StatusOK},
+		{name: "login wrong password", method: http.MethodPost, path: "/v1/auth/login",
+			body: `{"email":"alice@example.com","password":"wrong"}`, status: http.StatusUnauthorized},
+		{name: "login unknown email", method: http.MethodPost, path: "/v1/auth/login",
+			body: `{"email":"nobody@example.com","password":"password123"}`, status: http.StatusUnauthorized},
+		{name: "login missing password", method: http.MethodPost, path: "/v1/auth/login",
+			body: `{"email":"alice@example.com"}`, status: http.StatusBadRequest},
+		{name: "login invalid JSON", method: http.MethodPost, path: "/v1/auth/login",
+			body: `{"email":`, status: http.StatusBadRequest},
+
+		{name: "refresh", method: http.MethodPost, path: "/v1/auth/refresh",
+			body: `{"refresh_token":"{aliceRefresh}"}`, status: http.StatusOK},
+		{name: "refresh malformed token", method: http.MethodPost, path: "/v1/auth/refresh",
+			body: `{"refresh_token":"not-a-token"}`, status: http.StatusUnauthorized},
+		{name: "refresh unknown session", method: http.MethodPost, path: "/v1/auth/refresh",
+			body: `{"refresh_token":"{unknown}.secret"}`, status: http.StatusUnauthorized},
+		{name: "refresh missing token", method: http.MethodPost, path: "/v1/auth/refresh",
+			body: `{}`, status: http.StatusBadRequest},
+
+		{name: "logout", method: http.MethodPost, path: "/v1/auth/logout",
+			body: `{"refresh_token":"{aliceRefresh}"}`, status: http.StatusNoContent},
+		{name: "logout wrong secret", method: http.MethodPost, path: "/v1/auth/logout",
+			body: `{"refresh_token":"{aliceSession}.secret"}`, status: http.StatusUnauthorized},
+	})
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	env := newTestEnv(t)
+
+	rr := env.do(http.MethodPost, "/v1/auth/refresh", `{"refresh_token":"{aliceRefresh}"}`, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("first refresh: status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var tokens TokenResponse
+	env.decode(rr, &tokens)
+
+	// Replaying the rotated token revokes the session...
+	rr = env.do(http.MethodPost, "/v1/auth/refresh", `{"refresh_token":"{aliceRefresh}"}`, "")
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("replayed refresh: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	// ...so the token issued by the rotation no longer works either
+	rr = env.do(http.MethodPost, "/v1/auth/refresh", `{"refresh_token":"`+tokens.RefreshToken+`"}`, "")
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after reuse: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestLoginRecordsClientIP(t *testing.T) {
+	env := newTestEnv(t)
+
+	rr := env.do(http.MethodPost, "/v1/auth/login", `{"email":"alice@example.com","password":"password123"}`, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	aliceID, _ := store.ParseID(env.vars["alice"])
+	sessions, err := env.app.store.Sessions.GetActiveByUserID(context.Background(), aliceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The seeded session has no IP; httptest requests come from 192.0.2.1:1234
+	var ips []string
+	for _, s := range sessions {
+		if s.IP != "" {
+			ips = append(ips, s.IP)
+		}
+	}
+	if len(ips) != 1 || ips[0] != "192.0.2.1" {
+		t.Errorf("session IPs = %q, want the client address without its port", ips)
+	}
+}
+
+func TestLoginWithLegacyPasswordTooLongToRehash(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Plaintext passwords predate hashing and may exceed what bcrypt takes
+	password := strings.Repeat("p", 80)
+	user := &store.User{Username: "dave", Email: "dave@example.com", Password: password}
+	if err := env.app.store.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+
+	rr := env.do(http.MethodPost, "/v1/auth/login", `{"email":"dave@example.com","password":"`+password+`"}`, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateCommentRequest represents the JSON payload for commenting on a post.
+// Set ParentID to reply to another comment.
+type CreateCommentRequest struct {
+	Content  string `json:"content" validate:"required,max=2000"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+// UpdateCommentRequest represents the JSON payload for editing a comment
+type UpdateCommentRequest struct {
+	Content string `json:"content" validate:"required,max=2000"`
+}
+
+// AuthorResponse represents the author of a post or comment
+type AuthorResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// CommentResponse represents the JSON response for comment data
+type CommentResponse struct {
+	ID           string          `json:"id"`
+	PostID       string          `json:"post_id"`
+	ParentID     *string         `json:"parent_id"`
+	Depth        int             `json:"depth"`
+	Content      string          `json:"content"`
+	Author       *AuthorResponse `json:"author"` // nil if the author deleted their account
+	RepliesCount int64           `json:"replies_count"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// newCommentResponse builds the response for comment written by author
+func newCommentResponse(comment store.Comment, author *store.User) CommentResponse {
+	response := CommentResponse{
+		ID:           comment.ID.String(),
+		PostID:       comment.PostID.String(),
+		Depth:        comment.Depth,
+		Content:      comment.Content,
+		RepliesCount: comment.RepliesCount,
+		CreatedAt:    comment.CreatedAt,
+		UpdatedAt:    comment.UpdatedAt,
+	}
+	if comment.ParentID != nil {
+		parentID := comment.ParentID.String()
+		response.ParentID = &parentID
+	}
+	if author != nil {
+		response.Author = &AuthorResponse{ID: author.ID.String(), Username: author.Username}
+	}
+	return response
+}
+
+// createCommentHandler handles POST /v1/posts/{id}/comments
+func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid post ID format"))
+		return
+	}
+
+	var req CreateCommentRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	// Verify post exists
+	_, err = app.store.Posts.GetByID(r.Context(), postID)
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "post"))
+		return
+	}
+
+	user := getUserFromContext(r)
+	comment := &store.Comment{
+		PostID:  postID,
+		UserID:  user.ID,
+		Content: req.Content,
+	}
+
+	if req.ParentID != "" {
+		parentID, err := store.ParseID(req.ParentID)
+		if err != nil {
+			app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid parent ID format"))
+			return
+		}
+
+		parent, err := app.store.Comments.GetByID(r.Context(), parentID)
+		if errors.Is(err, store.ErrNotFound) {
+			app.errorResponse(w, r, badRequest(codeInvalidRequest, "Parent comment not found"))
+			return
+		}
+		if err != nil {
+			app.errorResponse(w, r, err)
+			return
+		}
+		if parent.Depth+1 > app.config.comments.maxDepth {
+			app.errorResponse(w, r, badRequest(codeInvalidRequest,
+				fmt.Sprintf("Replies cannot be nested more than %d levels deep", app.config.comments.maxDepth)))
+			return
+		}
+
+		comment.ParentID = &parentID
+	}
+
+	err = app.store.Comments.Create(r.Context(), comment)
+	if errors.Is(err, store.ErrParentMismatch) || errors.Is(err, store.ErrNotFound) {
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "Parent comment not found on this post"))
+		return
+	}
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSONResponse(w, http.StatusCreated, newCommentResponse(*comment, user))
+}
+
+// getCommentsHandler handles GET /v1/posts/{id}/comments
+// Lists top-level comments, or the replies to ?parent_id= when given.
+func (app *application) getCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid post ID format"))
+		return
+	}
+
+	var parentID *store.ID
+	if parentIDStr := r.URL.Query().Get("parent_id"); parentIDStr != "" {
+		id, err := store.ParseID(parentIDStr)
+		if err != nil {
+			app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid parent ID format"))
+			return
+		}
+		parentID = &id
+	}
+
+	page, err := readPage(r)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	// An unknown post has no comments rather than an empty list of them
+	if _, err := app.store.Posts.GetByID(r.Context(), postID); err != nil {
+		app.errorResponse(w, r, storeError(err, "post"))
+		return
+	}
+
+	comments, next, err := app.store.Comments.GetByPostID(r.Context(), postID, parentID, page)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	response := make([]CommentResponse, 0, len(comments))
+	for _, c := range comments {
+		response = append(response, newCommentResponse(c.Comment, c.User))
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"comments":    response,
+		"count":       len(response),
+		"next_cursor": encodeCursor(next),
+	})
+}
+
+// updateCommentHandler handles PATCH /v1/posts/{id}/comments/{commentID}
+func (app *application) updateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	commentID, ok := app.readCommentID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateCommentRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	err := app.store.Comments.Update(r.Context(), commentID, user.ID, req.Content)
+	if errors.Is(err, store.ErrForbidden) {
+		app.errorResponse(w, r, forbidden("You can only edit your own comments"))
+		return
+	}
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "comment"))
+		return
+	}
+
+	comment, err := app.store.Comments.GetByID(r.Context(), commentID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, newCommentResponse(*comment, user))
+}
+
+// deleteCommentHandler handles DELETE /v1/posts/{id}/comments/{commentID}
+// The comment's author and the post's owner may delete it; replies go with it.
+func (app *application) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	commentID, ok := app.readCommentID(w, r)
+	if !ok {
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	err := app.store.Comments.Delete(r.Context(), commentID, user.ID)
+	if errors.Is(err, store.ErrForbidden) {
+		app.errorResponse(w, r, forbidden("You can only delete your own comments or comments on your posts"))
+		return
+	}
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "comment"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readCommentID parses the {commentID} URL parameter and checks the comment
+// belongs to the {id} post. It writes the error response itself on failure.
+func (app *application) readCommentID(w http.ResponseWriter, r *http.Request) (store.ID, bool) {
+	postID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid post ID format"))
+		return "", false
+	}
+
+	commentID, err := store.ParseID(chi.URLParam(r, "commentID"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid comment ID format"))
+		return "", false
+	}
+
+	comment, err := app.store.Comments.GetByID(r.Context(), commentID)
+	if err == nil && comment.PostID != postID {
+		err = store.ErrNotFound
+	}
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "comment"))
+		return "", false
+	}
+
+	return commentID, true
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCommentRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "list", method: http.MethodGet, path: "/v1/posts/{alicePost}/comments", status: http.StatusOK},
+		{name: "list replies", method: http.MethodGet, path: "/v1/posts/{alicePost}/comments?parent_id={comment}", status: http.StatusOK},
+		{name: "list on post without comments", method: http.MethodGet, path: "/v1/posts/{bobPost}/comments", status: http.StatusOK},
+		{name: "list on unknown post", method: http.MethodGet, path: "/v1/posts/{unknown}/comments", status: http.StatusNotFound},
+		{name: "list invalid post ID", method: http.MethodGet, path: "/v1/posts/not-an-id/comments", status: http.StatusBadRequest},
+		{name: "list invalid parent ID", method: http.MethodGet, path: "/v1/posts/{alicePost}/comments?parent_id=nope", status: http.StatusBadRequest},
+
+		{name: "create", method: http.MethodPost, path: "/v1/posts/{bobPost}/comments",
+			body: `{"content":"Great write-up"}`, as: "carol", status: http.StatusCreated},
+		{name: "create reply", method: http.MethodPost, path: "/v1/posts/{alicePost}/comments",
+			body: `{"content":"Agreed","parent_id":"{comment}"}`, as: "carol", status: http.StatusCreated},
+		{name: "create reply too deep", method: http.MethodPost, path: "/v1/posts/{alicePost}/comments",
+			body: `{"content":"Too deep","parent_id":"{reply}"}`, as: "carol", status: http.StatusBadRequest},
+		{name: "create reply on other post", method: http.MethodPost, path: "/v1/posts/{bobPost}/comments",
+			body: `{"content":"Wrong thread","parent_id":"{comment}"}`, as: "carol", status: http.StatusBadRequest},
+		{name: "create reply to unknown comment", method: http.MethodPost, path: "/v1/posts/{alicePost}/comments",
+			body: `{"content":"Hello?","parent_id":"{unknown}"}`, as: "carol", status: http.StatusBadRequest},
+		{name: "create on unknown post", method: http.MethodPost, path: "/v1/posts/{unknown}/comments",
+			body: `{"content":"Hello?"}`, as: "carol", status: http.StatusNotFound},
+		{name: "create empty", method: http.MethodPost, path: "/v1/posts/{bobPost}/comments",
+			body: `{"content":""}`, as: "carol", status: http.StatusBadRequest},
+		{name: "create unauthenticated", method: http.MethodPost, path: "/v1/posts/{bobPost}/comments",
+			body: `{"content":"Hi"}`, status: http.StatusUnauthorized},
+
+		{name: "update", method: http.MethodPatch, path: "/v1/posts/{alicePost}/comments/{comment}",
+			body: `{"content":"Welcome aboard, Alice!"}`, as: "bob", status: http.StatusOK},
+		{name: "update other user's comment", method: http.MethodPatch, path: "/v1/posts/{alicePost}/comments/{comment}",
+			body: `{"content":"Edited"}`, as: "alice", status: http.StatusForbidden},
+		{name: "update under wrong post", method: http.MethodPatch, path: "/v1/posts/{bobPost}/comments/{comment}",
+			body: `{"content":"Edited"}`, as: "bob", status: http.StatusNotFound},
+		{name: "update invalid comment ID", method: http.MethodPatch, path: "/v1/posts/{alicePost}/comments/nope",
+			body: `{"content":"Edited"}`, as: "bob", status: http.StatusBadRequest},
+
+		{name: "delete own", method: http.MethodDelete, path: "/v1/posts/{alicePost}/comments/{reply}", as: "alice", status: http.StatusNoContent},
+		{name: "delete on own post", method: http.MethodDelete, path: "/v1/posts/{alicePost}/comments/{comment}", as: "alice", status: http.StatusNoContent},
+		{name: "delete other user's comment", method: http.MethodDelete, path: "/v1/posts/{alicePost}/comments/{comment}", as: "carol", status: http.StatusForbidden},
+		{name: "delete unknown", method: http.MethodDelete, path: "/v1/posts/{alicePost}/comments/{unknown}", as: "alice", status: http.StatusNotFound},
+	})
+}
+
+func TestDeleteCommentRemovesReplies(t *testing.T) {
+	env := newTestEnv(t)
+
+	if rr := env.do(http.MethodDelete, "/v1/posts/{alicePost}/comments/{comment}", "", "bob"); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+
+	var post PostResponse
+	env.decode(env.do(http.MethodGet, "/v1/posts/single?id={alicePost}", "", ""), &post)
+	if post.CommentsCount != 0 {
+		t.Errorf("comments_count = %d, want 0 once the thread is gone", post.CommentsCount)
+	}
+
+	rr := env.do(http.MethodPatch, "/v1/posts/{alicePost}/comments/{reply}", `{"content":"Still here?"}`, "alice")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("reply: status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
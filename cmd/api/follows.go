@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// FollowResponse represents a user in a followers/following listing
+type FollowResponse struct {
+	UserID     string    `json:"user_id"`
+	Username   string    `json:"username"`
+	Bio        string    `json:"bio"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// followUserHandler handles POST /v1/users/{id}/follow
+func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid user ID format"))
+		return
+	}
+
+	user := getUserFromContext(r)
+	if user.ID == followeeID {
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "You cannot follow yourself"))
+		return
+	}
+
+	// Verify user exists
+	_, err = app.store.Users.GetByID(r.Context(), followeeID)
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "user"))
+		return
+	}
+
+	if err := app.store.Follows.Follow(r.Context(), user.ID, followeeID); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unfollowUserHandler handles DELETE /v1/users/{id}/follow
+func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid user ID format"))
+		return
+	}
+
+	user := getUserFromContext(r)
+	if err := app.store.Follows.Unfollow(r.Context(), user.ID, followeeID); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getFollowersHandler handles GET /v1/users/{id}/followers
+func (app *application) getFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeFollowList(w, r, app.store.Follows.GetFollowers)
+}
+
+// getFollowingHandler handles GET /v1/users/{id}/following
+func (app *application) getFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeFollowList(w, r, app.store.Follows.GetFollowing)
+}
+
+// writeFollowList writes one page of a follow listing of the {id} user
+// together with their follower and following counts
+func (app *application) writeFollowList(w http.ResponseWriter, r *http.Request,
+	list func(context.Context, store.ID, store.Page) ([]store.FollowWithUser, *store.Cursor, error)) {
+	userID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid user ID format"))
+		return
+	}
+
+	page, err := readPage(r)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	// An unknown user has no listing rather than an empty one
+	if _, err := app.store.Users.GetByID(r.Context(), userID); err != nil {
+		app.errorResponse(w, r, storeError(err, "user"))
+		return
+	}
+
+	follows, next, err := list(r.Context(), userID, page)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	followers, following, err := app.store.Follows.GetCounts(r.Context(), userID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	users := make([]FollowResponse, 0, len(follows))
+	for _, f := range follows {
+		users = append(users, FollowResponse{
+			UserID:     f.User.ID.String(),
+			Username:   f.User.Username,
+			Bio:        f.User.Bio,
+			FollowedAt: f.CreatedAt,
+		})
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"users":           users,
+		"count":           len(users),
+		"followers_count": followers,
+		"following_count": following,
+		"next_cursor":     encodeCursor(next),
+	})
+}
+
+// getFeedHandler handles GET /v1/feed
+// Returns the authenticated user's timeline: their own posts and posts by
+// everyone they follow, newest first.
+func (app *application) getFeedHandler(w http.ResponseWriter, r *http.Request) {
+	page, err := readPage(r)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	posts, next, err := app.store.Posts.GetFeed(r.Context(), user.ID, page)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	viewerReactions, err := app.viewerReactionsByPost(r, postIDs(posts))
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"posts":       newPostListResponse(posts, viewerReactions),
+		"count":       len(posts),
+		"next_cursor": encodeCursor(next),
+	})
+}
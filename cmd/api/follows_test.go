@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFollowRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "followers", method: http.MethodGet, path: "/v1/users/{alice}/followers", status: http.StatusOK},
+		{name: "followers invalid ID", method: http.MethodGet, path: "/v1/users/not-an-id/followers", status: http.StatusBadRequest},
+		{name: "followers of unknown user", method: http.MethodGet, path: "/v1/users/{unknown}/followers", status: http.StatusNotFound},
+		{name: "following", method: http.MethodGet, path: "/v1/users/{alice}/following", status: http.StatusOK},
+		{name: "following of unknown user", method: http.MethodGet, path: "/v1/users/{unknown}/following", status: http.StatusNotFound},
+		{name: "following invalid limit", method: http.MethodGet, path: "/v1/users/{alice}/following?limit=0", status: http.StatusBadRequest},
+
+		{name: "follow", method: http.MethodPost, path: "/v1/users/{carol}/follow", as: "alice", status: http.StatusNoContent},
+		{name: "follow twice", method: http.MethodPost, path: "/v1/users/{bob}/follow", as: "alice", status: http.StatusNoContent},
+		{name: "follow self", method: http.MethodPost, path: "/v1/users/{alice}/follow", as: "alice", status: http.StatusBadRequest},
+		{name: "follow unknown user", method: http.MethodPost, path: "/v1/users/{unknown}/follow", as: "alice", status: http.StatusNotFound},
+		{name: "follow unauthenticated", method: http.MethodPost, path: "/v1/users/{bob}/follow", status: http.StatusUnauthorized},
+
+		{name: "unfollow", method: http.MethodDelete, path: "/v1/users/{bob}/follow", as: "alice", status: http.StatusNoContent},
+		{name: "unfollow invalid ID", method: http.MethodDelete, path: "/v1/users/not-an-id/follow", as: "alice", status: http.StatusBadRequest},
+
+		{name: "feed", method: http.MethodGet, path: "/v1/feed", as: "alice", status: http.StatusOK},
+		{name: "feed of user following no one", method: http.MethodGet, path: "/v1/feed", as: "bob", status: http.StatusOK},
+		{name: "feed unauthenticated", method: http.MethodGet, path: "/v1/feed", status: http.StatusUnauthorized},
+	})
+}
+
+func TestFollowUpdatesCounts(t *testing.T) {
+	env := newTestEnv(t)
+
+	if rr := env.do(http.MethodPost, "/v1/users/{alice}/follow", "", "bob"); rr.Code != http.StatusNoContent {
+		t.Fatalf("follow: status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+
+	var list struct {
+		Count          int   `json:"count"`
+		FollowersCount int64 `json:"followers_count"`
+		FollowingCount int64 `json:"following_count"`
+	}
+	env.decode(env.do(http.MethodGet, "/v1/users/{alice}/followers", "", ""), &list)
+	if list.Count != 2 || list.FollowersCount != 2 || list.FollowingCount != 1 {
+		t.Errorf("got %+v, want 2 followers listed and counted, 1 following", list)
+	}
+}
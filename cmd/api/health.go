@@ -1,9 +1,160 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync"
+	"time"
 
-func (app application) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("OK"))
-	// app.store.Users.Create(r.Context()) //<- Example usage of the store
-	// app.store.Posts.Create(r.Context()) //<- Example usage of the store
+	"github.com/Nutan-Kum12/Gopherso/internal/db"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Lifecycle phases of the server; only phaseReady passes readiness checks
+const (
+	phaseStarting int32 = iota // Listening, startup hooks such as migrations still running
+	phaseReady
+	phaseDraining // Shutdown started
+)
+
+var phaseNames = map[int32]string{
+	phaseStarting: "starting",
+	phaseReady:    "ready",
+	phaseDraining: "draining",
+}
+
+// healthCheck pings a dependency the API needs to serve requests and
+// returns stats worth reporting alongside its status
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) (map[string]any, error)
+}
+
+// HealthResponse describes the running build and, for readiness, the
+// status of each dependency
+type HealthResponse struct {
+	Status  string                      `json:"status"` // "ok" or "unavailable"
+	Phase   string                      `json:"phase"`
+	Version string                      `json:"version"`
+	Commit  string                      `json:"commit"`
+	Uptime  string                      `json:"uptime"`
+	Checks  map[string]DependencyHealth `json:"checks,omitempty"`
+}
+
+// DependencyHealth is the result of one dependency check
+type DependencyHealth struct {
+	Status  string         `json:"status"` // "ok" or "unavailable"
+	Latency string         `json:"latency"`
+	Error   string         `json:"error,omitempty"`
+	Stats   map[string]any `json:"stats,omitempty"`
+}
+
+// livenessHandler reports that the process is up. It checks no
+// dependencies, so an outage doesn't get healthy instances restarted.
+func (app application) livenessHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeJSONResponse(w, http.StatusOK, app.healthResponse("ok"))
+}
+
+// readinessHandler reports whether the API should receive traffic: it
+// fails while starting up or shutting down and when a dependency check fails
+func (app application) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	ready := app.phase.Load() == phaseReady
+	checks := app.runHealthChecks(r.Context())
+	for _, c := range checks {
+		if c.Status != "ok" {
+			ready = false
+		}
+	}
+
+	status, resp := http.StatusOK, app.healthResponse("ok")
+	if !ready {
+		status, resp = http.StatusServiceUnavailable, app.healthResponse("unavailable")
+	}
+	resp.Checks = checks
+	app.writeJSONResponse(w, status, resp)
+}
+
+func (app application) healthResponse(status string) HealthResponse {
+	return HealthResponse{
+		Status:  status,
+		Phase:   phaseNames[app.phase.Load()],
+		Version: app.build.version,
+		Commit:  app.build.commit,
+		Uptime:  time.Since(app.startedAt).Round(time.Second).String(),
+	}
+}
+
+// runHealthChecks runs the dependency checks concurrently, each bounded by
+// the configured timeout
+func (app application) runHealthChecks(ctx context.Context) map[string]DependencyHealth {
+	if len(app.healthChecks) == 0 {
+		return nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]DependencyHealth, len(app.healthChecks))
+	for _, hc := range app.healthChecks {
+		wg.Add(1)
+		go func(hc healthCheck) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, app.config.health.timeout)
+			defer cancel()
+
+			start := time.Now()
+			stats, err := hc.check(ctx)
+			result := DependencyHealth{
+				Status:  "ok",
+				Latency: time.Since(start).String(),
+				Stats:   stats,
+			}
+			if err != nil {
+				result.Status = "unavailable"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[hc.name] = result
+			mu.Unlock()
+		}(hc)
+	}
+	wg.Wait()
+	return results
+}
+
+// mongoHealthCheck pings the primary and reports pool usage
+func mongoHealthCheck(client *mongo.Client, monitor *db.MongoMonitor, maxPoolSize uint64) healthCheck {
+	return healthCheck{
+		name: "mongo",
+		check: func(ctx context.Context) (map[string]any, error) {
+			stats := map[string]any{
+				"max_pool_size":    maxPoolSize,
+				"open_connections": monitor.Open(),
+				"in_use":           monitor.InUse(),
+			}
+			return stats, client.Ping(ctx, readpref.Primary())
+		},
+	}
+}
+
+// postgresHealthCheck pings the database and reports pool usage
+func postgresHealthCheck(pg *sql.DB) healthCheck {
+	return healthCheck{
+		name: "postgres",
+		check: func(ctx context.Context) (map[string]any, error) {
+			s := pg.Stats()
+			stats := map[string]any{
+				"max_open_connections": s.MaxOpenConnections,
+				"open_connections":     s.OpenConnections,
+				"in_use":               s.InUse,
+				"idle":                 s.Idle,
+				"wait_count":           s.WaitCount,
+				"wait_duration":        s.WaitDuration.String(),
+			}
+			return stats, pg.PingContext(ctx)
+		},
+	}
 }
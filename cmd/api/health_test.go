@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "ok", method: http.MethodGet, path: "/v1/health", status: http.StatusOK},
+		{name: "live", method: http.MethodGet, path: "/v1/health/live", status: http.StatusOK},
+		{name: "ready", method: http.MethodGet, path: "/v1/health/ready", status: http.StatusOK},
+	})
+}
+
+func TestReadinessFollowsPhase(t *testing.T) {
+	tests := []struct {
+		phase      int32
+		liveStatus int
+		readStatus int
+	}{
+		{phase: phaseStarting, liveStatus: http.StatusOK, readStatus: http.StatusServiceUnavailable},
+		{phase: phaseReady, liveStatus: http.StatusOK, readStatus: http.StatusOK},
+		{phase: phaseDraining, liveStatus: http.StatusOK, readStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range tests {
+		t.Run(phaseNames[tc.phase], func(t *testing.T) {
+			env := newTestEnv(t)
+			env.app.phase.Store(tc.phase)
+
+			if rr := env.do(http.MethodGet, "/v1/health/live", "", ""); rr.Code != tc.liveStatus {
+				t.Errorf("live status = %d, want %d", rr.Code, tc.liveStatus)
+			}
+			rr := env.do(http.MethodGet, "/v1/health/ready", "", "")
+			if rr.Code != tc.readStatus {
+				t.Errorf("ready status = %d, want %d", rr.Code, tc.readStatus)
+			}
+			var resp HealthResponse
+			env.decode(rr, &resp)
+			if resp.Phase != phaseNames[tc.phase] {
+				t.Errorf("phase = %q, want %q", resp.Phase, phaseNames[tc.phase])
+			}
+		})
+	}
+}
+
+func TestReadinessReportsDependencies(t *testing.T) {
+	env := newTestEnv(t)
+	env.app.config.health.timeout = 50 * time.Millisecond
+	env.app.healthChecks = []healthCheck{
+		{name: "cache", check: func(ctx context.Context) (map[string]any, error) {
+			return map[string]any{"in_use": 1}, nil
+		}},
+		{name: "database", check: func(ctx context.Context) (map[string]any, error) {
+			<-ctx.Done() // Hangs until the check times out
+			return nil, ctx.Err()
+		}},
+	}
+	env.handler = env.app.mount()
+
+	rr := env.do(http.MethodGet, "/v1/health/ready", "", "")
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+	assertGolden(t, env.normalize(rr))
+
+	// Liveness doesn't depend on anything
+	if rr := env.do(http.MethodGet, "/v1/health/live", "", ""); rr.Code != http.StatusOK {
+		t.Errorf("live status = %d, want %d", rr.Code, http.StatusOK)
+	}
+}
+
+func TestRunFailsWhenStartupFails(t *testing.T) {
+	app := application{
+		logger: slog.New(slog.DiscardHandler),
+		config: config{addr: "127.0.0.1:0", shutdown: shutdownConfig{timeout: 5 * time.Second}},
+		phase:  new(atomic.Int32),
+		onStartup: []func(context.Context) error{func(ctx context.Context) error {
+			return errors.New("migration failed")
+		}},
+	}
+
+	err := app.run(http.NotFoundHandler())
+	if err == nil || !strings.Contains(err.Error(), "migration failed") {
+		t.Fatalf("run = %v, want the startup error", err)
+	}
+	if app.phase.Load() == phaseReady {
+		t.Error("marked ready after failed startup")
+	}
+}
+
+func TestRunShutsDownWhenListenerFails(t *testing.T) {
+	// Hold the port so the server can't listen on it
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	stopped := false
+	app := application{
+		logger: slog.New(slog.DiscardHandler),
+		config: config{addr: ln.Addr().String(), shutdown: shutdownConfig{timeout: 5 * time.Second}},
+		phase:  new(atomic.Int32),
+		onShutdown: []func(context.Context) error{func(ctx context.Context) error {
+			stopped = true
+			return nil
+		}},
+	}
+
+	if err := app.run(http.NotFoundHandler()); err == nil {
+		t.Fatal("run = nil, want the listen error")
+	}
+	if !stopped {
+		t.Error("shutdown hooks not run after the listener failed")
+	}
+}
+
+func TestShutdownDrainsRequestsThenWorkers(t *testing.T) {
+	var order []string
+	started := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		order = append(order, "request")
+		w.Write([]byte("done"))
+	})
+
+	app := application{
+		logger: slog.New(slog.DiscardHandler),
+		config: config{shutdown: shutdownConfig{timeout: 5 * time.Second}},
+		phase:  new(atomic.Int32),
+		onShutdown: []func(context.Context) error{func(ctx context.Context) error {
+			order = append(order, "workers")
+			return nil
+		}},
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: slow}
+	go srv.Serve(ln)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	app.shutdown(srv)
+
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q, want it to complete", got)
+	}
+	if app.phase.Load() != phaseDraining {
+		t.Error("not marked as draining")
+	}
+	if len(order) != 2 || order[0] != "request" || order[1] != "workers" {
+		t.Errorf("order = %v, want request then workers", order)
+	}
+}
@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-}
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
 
 // writeJSONResponse writes a JSON response with the given status code
 func (app *application) writeJSONResponse(w http.ResponseWriter, status int, data interface{}) {
@@ -18,20 +25,11 @@ func (app *application) writeJSONResponse(w http.ResponseWriter, status int, dat
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
+		app.logger.Error("Encoding JSON response", "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
 
-// writeErrorResponse writes an error response with the given status code and message
-func (app *application) writeErrorResponse(w http.ResponseWriter, status int, message string) {
-	errorResp := ErrorResponse{
-		Error:   http.StatusText(status),
-		Message: message,
-	}
-	app.writeJSONResponse(w, status, errorResp)
-}
-
 // writeSuccessResponse writes a success response with optional data
 func (app *application) writeSuccessResponse(w http.ResponseWriter, status int, message string, data interface{}) {
 	response := map[string]interface{}{
@@ -45,3 +43,238 @@ func (app *application) writeSuccessResponse(w http.ResponseWriter, status int,
 
 	app.writeJSONResponse(w, status, response)
 }
+
+// Problem is an RFC 7807 problem details body. Type is always
+// about:blank, so Title is the status text and clients branch on Code.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`                 // Stable machine-readable error code
+	RequestID string `json:"request_id,omitempty"` // Quote this when reporting a problem
+
+	// What is wrong with each invalid field, keyed by its JSON path
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Error codes reported in problems. These are part of the API: add new
+// ones rather than changing existing ones.
+const (
+	codeInvalidJSON       = "invalid_json"
+	codeInvalidRequest    = "invalid_request"
+	codeValidation        = "validation_failed"
+	codeBodyTooLarge      = "body_too_large"
+	codeInvalidID         = "invalid_id"
+	codeInvalidCursor     = "invalid_cursor"
+	codeInvalidToken      = "invalid_token"
+	codeUnauthorized      = "unauthorized"
+	codeInvalidCredential = "invalid_credentials"
+	codeForbidden         = "forbidden"
+	codeNotFound          = "not_found"
+	codeRouteNotFound     = "route_not_found"
+	codeMethodNotAllowed  = "method_not_allowed"
+	codeRateLimited       = "rate_limited"
+	codeAlreadyExists     = "already_exists"
+	codeUnavailable       = "unavailable"
+	codeInternal          = "internal_error"
+)
+
+// apiError is an error with the problem reported to the client for it
+type apiError struct {
+	status int
+	code   string
+	detail string
+	err    error             // Cause, logged for server errors and never exposed
+	fields map[string]string // Field errors of a request that failed validation
+}
+
+func (e *apiError) Error() string {
+	if e.err != nil {
+		return e.detail + ": " + e.err.Error()
+	}
+	return e.detail
+}
+
+func (e *apiError) Unwrap() error { return e.err }
+
+// errInvalidRefreshToken is reported for any refresh token that can't be
+// used, without saying why
+var errInvalidRefreshToken = unauthorized(codeInvalidToken, "Invalid refresh token")
+
+// badRequest reports a request the client must fix before retrying
+func badRequest(code, detail string) *apiError {
+	return &apiError{status: http.StatusBadRequest, code: code, detail: detail}
+}
+
+// unauthorized reports a missing or invalid credential
+func unauthorized(code, detail string) *apiError {
+	return &apiError{status: http.StatusUnauthorized, code: code, detail: detail}
+}
+
+// forbidden reports an authenticated caller acting on something it may not
+func forbidden(detail string) *apiError {
+	return &apiError{status: http.StatusForbidden, code: codeForbidden, detail: detail}
+}
+
+// storeError maps an error returned by internal/store to its problem.
+// resource names the entity the operation was about in client-facing
+// messages, e.g. "post".
+func storeError(err error, resource string) *apiError {
+	var dup *store.DuplicateError
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return &apiError{status: http.StatusNotFound, code: codeNotFound, detail: capitalize(resource) + " not found"}
+	case errors.As(err, &dup):
+		return &apiError{status: http.StatusConflict, code: codeAlreadyExists,
+			detail: fmt.Sprintf("%s with this %s already exists", capitalize(resource), dup.Field)}
+	case errors.Is(err, store.ErrConflict):
+		return &apiError{status: http.StatusConflict, code: codeAlreadyExists, detail: capitalize(resource) + " already exists"}
+	case errors.Is(err, store.ErrForbidden):
+		return forbidden(fmt.Sprintf("You can only modify your own %ss", resource))
+	case errors.Is(err, store.ErrInvalidID):
+		return badRequest(codeInvalidID, fmt.Sprintf("Invalid %s ID format", resource))
+	case errors.Is(err, store.ErrInvalidCursor):
+		return badRequest(codeInvalidCursor, "Invalid cursor")
+	case errors.Is(err, store.ErrInvalidCredentials):
+		return unauthorized(codeInvalidCredential, "Invalid email or password")
+	case errors.Is(err, store.ErrRefreshTokenReused):
+		return unauthorized(codeInvalidToken, "Refresh token reuse detected; session revoked")
+	case errors.Is(err, store.ErrSessionInvalid):
+		return errInvalidRefreshToken
+	case errors.Is(err, store.ErrPasswordTooLong):
+		return validationFailed(map[string]string{"password": "must be at most 72 bytes"})
+	case errors.Is(err, store.ErrInvalidEmailChangeToken):
+		return badRequest(codeInvalidToken, "Invalid or expired token")
+	case errors.Is(err, store.ErrUnavailable), errors.Is(err, context.DeadlineExceeded):
+		return &apiError{status: http.StatusServiceUnavailable, code: codeUnavailable,
+			detail: "The service is temporarily unavailable, try again later", err: err}
+	default:
+		return &apiError{status: http.StatusInternalServerError, code: codeInternal,
+			detail: "Something went wrong on our side", err: err}
+	}
+}
+
+// errorResponse writes err as an application/problem+json response. This
+// is where every error becomes an HTTP status: *apiError as given and
+// anything else through storeError. Server errors are logged with their
+// cause, which clients never see.
+func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		apiErr = storeError(err, "resource")
+	}
+
+	if apiErr.status >= http.StatusInternalServerError {
+		app.requestLogger(r).Error("Request failed", "code", apiErr.code, "error", err)
+	}
+	if apiErr.status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="gopherso"`)
+	}
+
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(apiErr.status),
+		Status:    apiErr.status,
+		Detail:    apiErr.detail,
+		Instance:  r.URL.Path,
+		Code:      apiErr.code,
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    apiErr.fields,
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(apiErr.status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		app.logger.Error("Encoding problem response", "error", err)
+	}
+}
+
+// notFoundHandler reports requests for paths no route matches
+func (app *application) notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	app.errorResponse(w, r, &apiError{status: http.StatusNotFound, code: codeRouteNotFound, detail: "No route matches " + r.URL.Path})
+}
+
+// methodNotAllowedHandler reports requests with a method the matched
+// route doesn't serve
+func (app *application) methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	app.errorResponse(w, r, &apiError{status: http.StatusMethodNotAllowed, code: codeMethodNotAllowed,
+		detail: r.Method + " is not allowed on " + r.URL.Path})
+}
+
+// capitalize upper-cases the first letter of an ASCII word
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// readLimit reads the limit query parameter of a listing, defaulting to
+// defaultPageLimit and capped at maxPageLimit
+func readLimit(r *http.Request) (int64, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil || limit < 1 {
+		return 0, badRequest(codeInvalidRequest, "limit must be a positive integer")
+	}
+	return min(limit, maxPageLimit), nil
+}
+
+// readPage reads the limit and cursor query parameters of a paginated
+// listing. The limit is capped at maxPageLimit.
+func readPage(r *http.Request) (store.Page, error) {
+	page := store.Page{Limit: defaultPageLimit}
+
+	limit, err := readLimit(r)
+	if err != nil {
+		return page, err
+	}
+	page.Limit = limit
+
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		cursor, err := store.DecodeCursor(cursorStr)
+		if err != nil {
+			return page, badRequest(codeInvalidCursor, "Invalid cursor")
+		}
+		page.After = cursor
+	}
+
+	return page, nil
+}
+
+// encodeCursor returns the opaque form of next, or nil on the last page
+func encodeCursor(next *store.Cursor) *string {
+	if next == nil {
+		return nil
+	}
+	s := next.Encode()
+	return &s
+}
+
+// routePattern returns the chi route pattern r matched, e.g.
+// /v1/posts/{id}, or "" if no route did. The pattern is complete only once
+// routing has finished, so middleware must call it after the handler.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	pattern := strings.ReplaceAll(rctx.RoutePattern(), "/*/", "/")
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
+
+// responseStatus returns the status code written through ww
+func responseStatus(ww middleware.WrapResponseWriter) int {
+	if ww.Status() == 0 {
+		return http.StatusOK // Handler wrote nothing
+	}
+	return ww.Status()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+func TestErrorResponses(t *testing.T) {
+	outage := errors.New("dial tcp 10.0.0.1:27017: connection refused")
+
+	tests := []struct {
+		name         string
+		method, path string
+		as           string
+		down         bool // Every store call fails with ErrUnavailable
+		status       int
+		code         string
+	}{
+		{name: "unknown route", method: http.MethodGet, path: "/v1/nope",
+			status: http.StatusNotFound, code: codeRouteNotFound},
+		{name: "method not allowed", method: http.MethodPut, path: "/v1/posts",
+			status: http.StatusMethodNotAllowed, code: codeMethodNotAllowed},
+		{name: "store unavailable", method: http.MethodGet, path: "/v1/posts", down: true,
+			status: http.StatusServiceUnavailable, code: codeUnavailable},
+		{name: "store unavailable while authenticating", method: http.MethodGet, path: "/v1/feed", as: "alice", down: true,
+			status: http.StatusServiceUnavailable, code: codeUnavailable},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			if tc.down {
+				env.app.store = store.WrapErrors(env.app.store, func(error) error {
+					return fmt.Errorf("%w: %w", store.ErrUnavailable, outage)
+				})
+				env.handler = env.app.mount()
+			}
+
+			rr := env.do(tc.method, tc.path, "", tc.as)
+			if rr.Code != tc.status {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.status, rr.Body.String())
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", ct)
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != tc.code || problem.Status != tc.status {
+				t.Errorf("problem = %+v, want code %s and status %d", problem, tc.code, tc.status)
+			}
+			if strings.Contains(rr.Body.String(), "connection refused") {
+				t.Errorf("problem leaks the cause: %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestStoreError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{store.ErrNotFound, http.StatusNotFound, codeNotFound, "Post not found"},
+		{&store.DuplicateError{Field: "title"}, http.StatusConflict, codeAlreadyExists, "Post with this title already exists"},
+		{store.ErrForbidden, http.StatusForbidden, codeForbidden, "You can only modify your own posts"},
+		{store.ErrInvalidCursor, http.StatusBadRequest, codeInvalidCursor, "Invalid cursor"},
+		{fmt.Errorf("%w: reset by peer", store.ErrUnavailable), http.StatusServiceUnavailable, codeUnavailable,
+			"The service is temporarily unavailable, try again later"},
+		{errors.New("boom"), http.StatusInternalServerError, codeInternal, "Something went wrong on our side"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			got := storeError(fmt.Errorf("posts.Update: %w", tc.err), "post")
+			if got.status != tc.status || got.code != tc.code || got.detail != tc.detail {
+				t.Errorf("storeError = %d %s %q, want %d %s %q", got.status, got.code, got.detail, tc.status, tc.code, tc.detail)
+			}
+		})
+	}
+}
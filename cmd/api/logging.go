@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const requestLogContextKey contextKey = "requestLog"
+
+// requestLog holds the logger of a request. Middleware deeper in the chain
+// adds attributes as it learns them, e.g. the authenticated user, and they
+// appear on every later log line including the one completing the request.
+type requestLog struct {
+	logger *slog.Logger
+}
+
+// requestLogMiddleware logs each request on completion with its route,
+// status and latency, and gives handlers a logger carrying the request ID
+func (app application) requestLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		logger := app.logger.With(
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+		if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+			logger = logger.With(slog.String("trace_id", span.TraceID().String()))
+		}
+		entry := &requestLog{logger: logger}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), requestLogContextKey, entry)))
+
+		status := responseStatus(ww)
+		level := slog.LevelInfo
+		switch route := routePattern(r); {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case strings.HasPrefix(route, "/v1/health") || route == "/metrics":
+			level = slog.LevelDebug // Probes and scrapes would drown everything else
+		}
+		entry.logger.LogAttrs(r.Context(), level, "Request completed",
+			slog.String("route", routePattern(r)),
+			slog.Int("status", status),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+	})
+}
+
+// requestLogger returns the logger of the request r, falling back to the
+// application logger outside of requestLogMiddleware
+func (app application) requestLogger(r *http.Request) *slog.Logger {
+	if entry, ok := r.Context().Value(requestLogContextKey).(*requestLog); ok {
+		return entry.logger
+	}
+	return app.logger
+}
+
+// addRequestLogAttrs adds attributes to the logger of the request ctx
+// belongs to
+func addRequestLogAttrs(ctx context.Context, attrs ...any) {
+	if entry, ok := ctx.Value(requestLogContextKey).(*requestLog); ok {
+		entry.logger = entry.logger.With(attrs...)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/logging"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		as     string
+		fields map[string]any
+	}{
+		{
+			name: "authenticated",
+			path: "/v1/users/me/sessions",
+			as:   "alice",
+			fields: map[string]any{
+				"level":      "INFO",
+				"msg":        "Request completed",
+				"request_id": "req-1",
+				"user_id":    "{alice}",
+				"method":     "GET",
+				"path":       "/v1/users/me/sessions",
+				"route":      "/v1/users/me/sessions",
+				"status":     float64(http.StatusOK),
+			},
+		},
+		{
+			name: "anonymous not found",
+			path: "/v1/posts/single?id={unknown}",
+			fields: map[string]any{
+				"level":   "INFO",
+				"route":   "/v1/posts/single",
+				"status":  float64(http.StatusNotFound),
+				"user_id": nil,
+			},
+		},
+		{
+			name: "probe",
+			path: "/v1/health/live",
+			fields: map[string]any{
+				"level": "DEBUG",
+				"route": "/v1/health/live",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			var buf bytes.Buffer
+			logger, err := logging.New(&buf, "debug", "json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			env.app.logger = logger
+			env.handler = env.app.mount()
+
+			req := httptest.NewRequest(http.MethodGet, env.expand(tc.path), nil)
+			req.Header.Set(middleware.RequestIDHeader, "req-1")
+			if tc.as != "" {
+				req.Header.Set("Authorization", "Bearer "+env.tokens[tc.as])
+			}
+			env.handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("want one JSON log line, got %q: %v", buf.String(), err)
+			}
+			if _, ok := entry["latency_ms"].(float64); !ok {
+				t.Errorf("latency_ms = %v, want a number", entry["latency_ms"])
+			}
+			for key, want := range tc.fields {
+				got := entry[key]
+				if s, ok := got.(string); ok {
+					got = env.scrub("", s)
+				}
+				if got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics holds the Prometheus registry served on /metrics along with the
+// HTTP request metrics recorded by metricsMiddleware
+type metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	rateLimited     *prometheus.CounterVec
+}
+
+// newMetrics returns a registry with Go runtime, process and HTTP metrics.
+// Stores and database clients register theirs with metrics.registry.
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopherso_http_requests_total",
+			Help: "HTTP requests by method, route pattern and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gopherso_http_request_duration_seconds",
+			Help:    "Latency of HTTP requests by method, route pattern and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopherso_http_rate_limited_total",
+			Help: "Requests rejected for exceeding a rate limit policy.",
+		}, []string{"policy"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.rateLimited,
+	)
+	return m
+}
+
+// metricsHandler serves the registry in the Prometheus exposition format
+func (app application) metricsHandler() http.Handler {
+	return promhttp.HandlerFor(app.metrics.registry, promhttp.HandlerOpts{})
+}
+
+// metricsMiddleware records each request under the chi route pattern it
+// matched rather than the raw URL, which would give every post its own
+// time series
+func (app application) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := routePattern(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(responseStatus(ww))}
+		app.metrics.requests.With(labels).Inc()
+		app.metrics.requestDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricsRoute(t *testing.T) {
+	env := newTestEnv(t)
+	env.cover(http.MethodGet, "/metrics")
+
+	env.do(http.MethodGet, "/v1/posts/single?id={alicePost}", "", "")
+	env.do(http.MethodGet, "/v1/posts/single?id={unknown}", "", "")
+	env.do(http.MethodGet, "/v1/posts/", "", "")
+	env.do(http.MethodGet, "/no/such/route", "", "")
+
+	rr := env.do(http.MethodGet, "/metrics", "", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	body := rr.Body.String()
+	for _, want := range []string{
+		// Labeled by route pattern, not by the URL with its IDs
+		`gopherso_http_requests_total{method="GET",route="/v1/posts/single",status="200"} 1`,
+		`gopherso_http_requests_total{method="GET",route="/v1/posts/single",status="404"} 1`,
+		`gopherso_http_requests_total{method="GET",route="/v1/posts",status="200"} 1`,
+		`gopherso_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`gopherso_http_request_duration_seconds_count{method="GET",route="/v1/posts/single",status="200"} 1`,
+
+		`gopherso_store_operation_duration_seconds_count{op="GetByID",store="posts"} 2`,
+		`gopherso_store_operation_errors_total{kind="not_found",op="GetByID",store="posts"} 1`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+type contextKey string
+
+const (
+	userContextKey    contextKey = "user"
+	sessionContextKey contextKey = "session"
+)
+
+// authTokenMiddleware resolves the bearer token on the request into the
+// calling user and stores it in the request context
+func (app *application) authTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			app.errorResponse(w, r, errMalformedAuthHeader)
+			return
+		}
+
+		ctx, err := app.authenticate(r)
+		if err != nil {
+			app.errorResponse(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// realIPMiddleware replaces RemoteAddr with the client address forwarded
+// by a trusted proxy. Headers from any other peer are ignored, since
+// clients could forge them to pick their rate limit bucket.
+func (app application) realIPMiddleware(next http.Handler) http.Handler {
+	if len(app.config.trustedProxies) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := app.forwardedIP(r); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedIP returns the client address a trusted proxy forwarded r for,
+// or "" if r didn't come through one. X-Forwarded-For is read from the
+// right, skipping trusted proxies, as entries left of them are unchecked.
+func (app application) forwardedIP(r *http.Request) string {
+	peer, ok := parseAddr(r.RemoteAddr)
+	if !ok || !app.isTrustedProxy(peer) {
+		return ""
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, ok := parseAddr(strings.TrimSpace(hops[i]))
+			if !ok {
+				return ""
+			}
+			if i == 0 || !app.isTrustedProxy(addr) {
+				return addr.String()
+			}
+		}
+	}
+
+	if addr, ok := parseAddr(r.Header.Get("X-Real-IP")); ok {
+		return addr.String()
+	}
+	return ""
+}
+
+// isTrustedProxy reports whether addr belongs to a trusted proxy
+func (app application) isTrustedProxy(addr netip.Addr) bool {
+	for _, prefix := range app.config.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client r came from, as resolved by
+// realIPMiddleware and without the port of the connection
+func clientIP(r *http.Request) string {
+	if addr, ok := parseAddr(r.RemoteAddr); ok {
+		return addr.String()
+	}
+	return r.RemoteAddr
+}
+
+// parseAddr parses an IP address with or without a port
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// parseTrustedProxies parses a comma-separated list of proxy addresses and
+// CIDR ranges, e.g. "10.0.0.0/8,192.0.2.7"
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if addr, err := netip.ParseAddr(item); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", item)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// optionalAuthTokenMiddleware is like authTokenMiddleware but lets
+// anonymous requests through. A token that is present must still be valid.
+func (app *application) optionalAuthTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := app.authenticate(r)
+		if err != nil {
+			app.errorResponse(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Problems reported for requests that fail authentication
+var (
+	errMalformedAuthHeader = unauthorized(codeUnauthorized, "Missing or malformed authorization header")
+	errInvalidAccessToken  = unauthorized(codeInvalidToken, "Invalid or expired token")
+)
+
+// authenticate validates the request's bearer token and returns a context
+// carrying the caller, or the error explaining why it was rejected
+func (app *application) authenticate(r *http.Request) (context.Context, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, errMalformedAuthHeader
+	}
+
+	claims, err := app.authenticator.ValidateToken(token)
+	if err != nil {
+		return nil, errInvalidAccessToken
+	}
+
+	userID, err := store.ParseID(claims.Subject)
+	if err != nil {
+		return nil, errInvalidAccessToken
+	}
+
+	// A token for a deleted user is as good as expired, but a failed
+	// lookup is not the caller's fault
+	user, err := app.store.Users.GetByID(r.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, errInvalidAccessToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Access tokens die with their session, so logging out or revoking a
+	// session takes effect immediately rather than when the token expires
+	sessionID, err := store.ParseID(claims.SessionID)
+	if err != nil {
+		return nil, errInvalidAccessToken
+	}
+	session, err := app.store.Sessions.GetByID(r.Context(), sessionID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, errInvalidAccessToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !session.IsActive(time.Now()) || session.UserID != user.ID {
+		return nil, errInvalidAccessToken
+	}
+
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	ctx = context.WithValue(ctx, sessionContextKey, claims.SessionID)
+	addRequestLogAttrs(ctx, slog.String("user_id", user.ID.String()))
+	return ctx, nil
+}
+
+// getUserFromContext returns the authenticated user set by authTokenMiddleware,
+// or nil for anonymous requests
+func getUserFromContext(r *http.Request) *store.User {
+	user, _ := r.Context().Value(userContextKey).(*store.User)
+	return user
+}
+
+// getSessionIDFromContext returns the session ID the caller's access token
+// was issued for
+func getSessionIDFromContext(r *http.Request) string {
+	sessionID, _ := r.Context().Value(sessionContextKey).(string)
+	return sessionID
+}
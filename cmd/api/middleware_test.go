@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthTokenMiddleware(t *testing.T) {
+	env := newTestEnv(t)
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{name: "valid token", header: "Bearer " + env.tokens["alice"], status: http.StatusOK},
+		{name: "scheme is case-insensitive", header: "bearer " + env.tokens["alice"], status: http.StatusOK},
+		{name: "missing header", header: "", status: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic " + env.tokens["alice"], status: http.StatusUnauthorized},
+		{name: "empty token", header: "Bearer ", status: http.StatusUnauthorized},
+		{name: "garbage token", header: "Bearer not.a.jwt", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/me/sessions", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+
+			rr := httptest.NewRecorder()
+			env.handler.ServeHTTP(rr, req)
+			if rr.Code != tc.status {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.status, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthTokenMiddlewareRejectsDeletedUser(t *testing.T) {
+	env := newTestEnv(t)
+
+	if rr := env.do(http.MethodDelete, "/v1/users/{carol}", "", "carol"); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+	if rr := env.do(http.MethodGet, "/v1/users/me/sessions", "", "carol"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthTokenMiddlewareRejectsEndedSession(t *testing.T) {
+	env := newTestEnv(t)
+
+	body := `{"refresh_token":"{aliceRefresh}"}`
+	if rr := env.do(http.MethodPost, "/v1/auth/logout", body, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("logout: status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+	if rr := env.do(http.MethodGet, "/v1/users/me/sessions", "", "alice"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("after logout: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	// A token naming another user's session is no good either
+	token, _, err := env.app.authenticator.GenerateToken(env.vars["carol"], env.vars["bobSession"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/me/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	env.handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("foreign session: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRealIPMiddleware(t *testing.T) {
+	proxies, err := parseTrustedProxies("10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		peer    string
+		headers map[string]string
+		want    string
+	}{
+		{name: "direct client", peer: "203.0.113.5:1234", want: "203.0.113.5:1234"},
+		{name: "forged header from client", peer: "203.0.113.5:1234",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.9"}, want: "203.0.113.5:1234"},
+		{name: "forwarded by proxy", peer: "192.0.2.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.9"}, want: "198.51.100.9"},
+		{name: "forged hop before proxy chain", peer: "192.0.2.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.9, 10.1.2.3"}, want: "198.51.100.9"},
+		{name: "real ip from proxy", peer: "10.0.0.2:1234",
+			headers: map[string]string{"X-Real-IP": "198.51.100.9"}, want: "198.51.100.9"},
+		{name: "proxy without headers", peer: "10.0.0.2:1234", want: "10.0.0.2:1234"},
+		{name: "garbage header from proxy", peer: "10.0.0.2:1234",
+			headers: map[string]string{"X-Forwarded-For": "not-an-ip"}, want: "10.0.0.2:1234"},
+	}
+
+	app := application{config: config{trustedProxies: proxies}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			handler := app.realIPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.peer
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tc.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsInvalid(t *testing.T) {
+	if _, err := parseTrustedProxies("10.0.0.0/8,proxy.internal"); err == nil {
+		t.Error("no error for a host name")
+	}
+}
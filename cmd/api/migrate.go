@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/db"
+)
+
+// startupMigrationTimeout bounds waiting for the migration lock and
+// applying pending migrations when the API boots
+const startupMigrationTimeout = 5 * time.Minute
+
+const migrateUsage = `usage: gopherso migrate <command>
+
+Commands:
+  status  list migrations and whether they are applied
+  up      apply all pending migrations
+  down    roll back the most recently applied migration
+  to N    migrate up or down to version N (0 rolls back everything)`
+
+// migrateOnStart returns a startup hook applying pending migrations. The
+// API reports not ready until it finishes.
+func migrateOnStart(migrator *db.Migrator) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, startupMigrationTimeout)
+		defer cancel()
+		return migrator.Up(ctx)
+	}
+}
+
+// runMigrate runs the migrate subcommand against the configured database
+func runMigrate(cfg dbConfig, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	var target int
+	switch args[0] {
+	case "status", "up", "down":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		target = version
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	migrator, closeDB, err := openMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	// Interrupting stops before the next migration or while waiting for the lock
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printMigrationStatus(os.Stdout, statuses)
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	default:
+		return migrator.To(ctx, target)
+	}
+}
+
+// openMigrator connects to the database of cfg.driver and returns its
+// migrator along with a function closing the connection
+func openMigrator(cfg dbConfig) (*db.Migrator, func(), error) {
+	switch cfg.driver {
+	case "mongo":
+		client, err := db.New(cfg.uri, cfg.maxPoolSize, cfg.minPoolSize, cfg.maxIdleTime, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("connecting to database: %w", err)
+		}
+		return db.NewMongoMigrator(client.Database(cfg.name)), func() {
+			client.Disconnect(context.Background())
+		}, nil
+	case "postgres":
+		pg, err := db.NewPostgres(cfg.uri, int(cfg.maxPoolSize), int(cfg.minPoolSize), cfg.maxIdleTime)
+		if err != nil {
+			return nil, nil, fmt.Errorf("connecting to database: %w", err)
+		}
+		migrator, err := db.NewPostgresMigrator(pg)
+		if err != nil {
+			pg.Close()
+			return nil, nil, err
+		}
+		return migrator, func() { pg.Close() }, nil
+	case "memory":
+		return nil, nil, errors.New("DB_DRIVER=memory has no schema to migrate")
+	default:
+		return nil, nil, fmt.Errorf("unknown DB_DRIVER %q", cfg.driver)
+	}
+}
+
+// printMigrationStatus writes statuses as a table
+func printMigrationStatus(w io.Writer, statuses []db.MigrationStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tDESCRIPTION\tAPPLIED AT")
+	for _, s := range statuses {
+		description := s.Description
+		if !s.Known {
+			description = "(unknown, applied by a newer build)"
+		}
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, description, appliedAt)
+	}
+	return tw.Flush()
+}
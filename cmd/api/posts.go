@@ -1,94 +1,123 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/Nutan-Kum12/Gopherso/internal/store"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/go-chi/chi/v5"
 )
 
 // CreatePostRequest represents the JSON payload for creating a post
 type CreatePostRequest struct {
 	Title   string   `json:"title" validate:"required,max=200"`
 	Content string   `json:"content" validate:"required,max=5000"`
-	UserID  string   `json:"user_id" validate:"required"`
-	Tags    []string `json:"tags,omitempty"`
+	Tags    []string `json:"tags,omitempty" validate:"max=10,dive,tag"`
+}
+
+// UpdatePostRequest represents the JSON payload for partially updating a post.
+// Omitted fields are left unchanged.
+type UpdatePostRequest struct {
+	Title   *string   `json:"title" validate:"omitnil,min=1,max=200"`
+	Content *string   `json:"content" validate:"omitnil,min=1,max=5000"`
+	Tags    *[]string `json:"tags" validate:"omitnil,max=10,dive,tag"`
 }
 
 // PostResponse represents the JSON response for post data
 type PostResponse struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	UserID    string    `json:"user_id"`
-	Tags      []string  `json:"tags"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            string           `json:"id"`
+	Title         string           `json:"title"`
+	Content       string           `json:"content"`
+	UserID        string           `json:"user_id"`
+	Tags          []string         `json:"tags"`
+	CommentsCount int64            `json:"comments_count"`
+	Reactions     map[string]int64 `json:"reactions"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+
+	// Reaction kinds the authenticated caller left, possibly none; omitted
+	// for anonymous requests
+	ViewerReactions []string `json:"viewer_reactions,omitzero"`
 }
 
-// createPostHandler handles POST /v1/posts
-func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request) {
-	var req CreatePostRequest
+// PostWithAuthorResponse represents a post in a listing along with its author
+type PostWithAuthorResponse struct {
+	PostResponse
+	Author *AuthorResponse `json:"author"` // nil if the author deleted their account
+}
 
-	// Decode JSON request
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
-		return
+// newPostResponse builds the response for post
+func newPostResponse(post *store.Post) PostResponse {
+	return PostResponse{
+		ID:            post.ID.String(),
+		Title:         post.Title,
+		Content:       post.Content,
+		UserID:        post.UserID.String(),
+		Tags:          post.Tags,
+		CommentsCount: post.CommentsCount,
+		Reactions:     reactionCounts(post),
+		CreatedAt:     post.CreatedAt,
+		UpdatedAt:     post.UpdatedAt,
 	}
+}
 
-	// Basic validation
-	if req.Title == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Title is required")
-		return
-	}
-	if req.Content == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Content is required")
-		return
+// newPostWithAuthorResponse builds the response for a post with its
+// author. Only public author fields are included, never their email.
+func newPostWithAuthorResponse(post *store.PostWithUser) PostWithAuthorResponse {
+	response := PostWithAuthorResponse{PostResponse: newPostResponse(&post.Post)}
+	if author := post.User; author.ID != "" {
+		response.Author = &AuthorResponse{ID: author.ID.String(), Username: author.Username}
 	}
-	if req.UserID == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "User ID is required")
-		return
+	return response
+}
+
+// newPostListResponse builds the responses for posts listed with their
+// authors, along with the viewer's reactions from viewerReactionsByPost
+func newPostListResponse(posts []store.PostWithUser, viewerReactions map[store.ID][]string) []PostWithAuthorResponse {
+	response := make([]PostWithAuthorResponse, 0, len(posts))
+	for i := range posts {
+		item := newPostWithAuthorResponse(&posts[i])
+		item.ViewerReactions = viewerReactions[posts[i].ID]
+		response = append(response, item)
 	}
+	return response
+}
 
-	// Convert user ID to ObjectID
-	userID, err := primitive.ObjectIDFromHex(req.UserID)
-	if err != nil {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID format")
+// createPostHandler handles POST /v1/posts
+// The author is the authenticated user, never a field of the payload.
+func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreatePostRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	// Verify user exists
-	_, err = app.store.Users.GetByID(r.Context(), userID)
+	tags, err := store.NormalizeTags(req.Tags)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusBadRequest, "User not found")
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, err.Error()))
 		return
 	}
 
+	user := getUserFromContext(r)
+
 	// Create post
 	post := &store.Post{
 		Title:   req.Title,
 		Content: req.Content,
-		UserID:  userID,
-		Tags:    req.Tags,
+		UserID:  user.ID,
+		Tags:    tags,
 	}
 
 	if err := app.store.Posts.Create(r.Context(), post); err != nil {
-		app.writeErrorResponse(w, http.StatusInternalServerError, "Failed to create post")
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	// Return post response
-	response := PostResponse{
-		ID:        post.ID.Hex(),
-		Title:     post.Title,
-		Content:   post.Content,
-		UserID:    post.UserID.Hex(),
-		Tags:      post.Tags,
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
-	}
+	// A new post has no reactions yet
+	response := newPostResponse(post)
+	response.ViewerReactions = []string{}
 
 	app.writeJSONResponse(w, http.StatusCreated, response)
 }
@@ -97,30 +126,27 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 	postIDStr := r.URL.Query().Get("id")
 	if postIDStr == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Post ID is required")
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "Post ID is required"))
 		return
 	}
 
-	postID, err := primitive.ObjectIDFromHex(postIDStr)
+	postID, err := store.ParseID(postIDStr)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID format")
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid post ID format"))
 		return
 	}
 
 	post, err := app.store.Posts.GetByID(r.Context(), postID)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusNotFound, "Post not found")
+		app.errorResponse(w, r, storeError(err, "post"))
 		return
 	}
 
-	response := PostResponse{
-		ID:        post.ID.Hex(),
-		Title:     post.Title,
-		Content:   post.Content,
-		UserID:    post.UserID.Hex(),
-		Tags:      post.Tags,
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
+	response := newPostResponse(post)
+	response.ViewerReactions, err = app.viewerReactions(r, postID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
 	}
 
 	app.writeJSONResponse(w, http.StatusOK, response)
@@ -130,39 +156,57 @@ func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 func (app *application) getPostWithUserHandler(w http.ResponseWriter, r *http.Request) {
 	postIDStr := r.URL.Query().Get("id")
 	if postIDStr == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Post ID is required")
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "Post ID is required"))
 		return
 	}
 
-	postID, err := primitive.ObjectIDFromHex(postIDStr)
+	postID, err := store.ParseID(postIDStr)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID format")
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid post ID format"))
 		return
 	}
 
 	postWithUser, err := app.store.Posts.GetWithUser(r.Context(), postID)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusNotFound, "Post not found")
+		app.errorResponse(w, r, storeError(err, "post"))
+		return
+	}
+
+	response := newPostWithAuthorResponse(postWithUser)
+	response.ViewerReactions, err = app.viewerReactions(r, postID)
+	if err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	app.writeJSONResponse(w, http.StatusOK, postWithUser)
+	app.writeJSONResponse(w, http.StatusOK, response)
 }
 
 // getPostsHandler handles GET /v1/posts (get all posts with user info)
+// Paginated with ?limit= and ?cursor= (the next_cursor of the previous page).
 func (app *application) getPostsHandler(w http.ResponseWriter, r *http.Request) {
-	// Get limit from query parameter (default to 20)
-	limit := int64(20)
+	page, err := readPage(r)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
 
-	posts, err := app.store.Posts.GetAllWithUsers(r.Context(), limit)
+	posts, next, err := app.store.Posts.GetAllWithUsers(r.Context(), page)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	viewerReactions, err := app.viewerReactionsByPost(r, postIDs(posts))
+	if err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
 	app.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"posts": posts,
-		"count": len(posts),
+		"posts":       newPostListResponse(posts, viewerReactions),
+		"count":       len(posts),
+		"next_cursor": encodeCursor(next),
 	})
 }
 
@@ -170,24 +214,124 @@ func (app *application) getPostsHandler(w http.ResponseWriter, r *http.Request)
 func (app *application) getPostsByUserHandler(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.URL.Query().Get("user_id")
 	if userIDStr == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "User ID is required")
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "User ID is required"))
+		return
+	}
+
+	userID, err := store.ParseID(userIDStr)
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid user ID format"))
 		return
 	}
 
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	page, err := readPage(r)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID format")
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	posts, err := app.store.Posts.GetByUserID(r.Context(), userID)
+	posts, next, err := app.store.Posts.GetByUserID(r.Context(), userID, page)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve user posts")
+		app.errorResponse(w, r, err)
 		return
 	}
 
+	viewerReactions, err := app.viewerReactionsByPost(r, postIDs(posts))
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	response := make([]PostResponse, 0, len(posts))
+	for i := range posts {
+		item := newPostResponse(&posts[i])
+		item.ViewerReactions = viewerReactions[posts[i].ID]
+		response = append(response, item)
+	}
+
 	app.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"posts": posts,
-		"count": len(posts),
+		"posts":       response,
+		"count":       len(posts),
+		"next_cursor": encodeCursor(next),
 	})
 }
+
+// updatePostHandler handles PATCH /v1/posts/{id}
+func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid post ID format"))
+		return
+	}
+
+	var req UpdatePostRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	changes := store.PostUpdate{Title: req.Title, Content: req.Content}
+	if req.Tags != nil {
+		tags, err := store.NormalizeTags(*req.Tags)
+		if err != nil {
+			app.errorResponse(w, r, badRequest(codeInvalidRequest, err.Error()))
+			return
+		}
+		changes.Tags = &tags
+	}
+	if changes == (store.PostUpdate{}) {
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "No fields to update"))
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	err = app.store.Posts.Update(r.Context(), postID, user.ID, changes)
+	if errors.Is(err, store.ErrForbidden) {
+		app.errorResponse(w, r, forbidden("You can only update your own posts"))
+		return
+	}
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "post"))
+		return
+	}
+
+	post, err := app.store.Posts.GetByID(r.Context(), postID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	response := newPostResponse(post)
+	response.ViewerReactions, err = app.viewerReactions(r, post.ID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// deletePostHandler handles DELETE /v1/posts/{id}
+func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid post ID format"))
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	err = app.store.Posts.Delete(r.Context(), postID, user.ID)
+	if errors.Is(err, store.ErrForbidden) {
+		app.errorResponse(w, r, forbidden("You can only delete your own posts"))
+		return
+	}
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "post"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
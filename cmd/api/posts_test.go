@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPostRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "create", method: http.MethodPost, path: "/v1/posts",
+			body: `{"title":"Generics","content":"Type parameters landed in Go 1.18.","tags":[" #Go ","Generics","go"]}`,
+			as:   "alice", status: http.StatusCreated},
+		{name: "create without tags", method: http.MethodPost, path: "/v1/posts",
+			body: `{"title":"Untagged","content":"No tags here."}`, as: "alice", status: http.StatusCreated},
+		{name: "create missing title", method: http.MethodPost, path: "/v1/posts",
+			body: `{"content":"No title"}`, as: "alice", status: http.StatusBadRequest},
+		{name: "create invalid tag", method: http.MethodPost, path: "/v1/posts",
+			body: `{"title":"Bad tag","content":"x","tags":["no spaces allowed"]}`, as: "alice", status: http.StatusBadRequest},
+		{name: "create too many tags", method: http.MethodPost, path: "/v1/posts",
+			body: `{"title":"Tags","content":"x","tags":["a","b","c","d","e","f","g","h","i","j","k"]}`, as: "alice", status: http.StatusBadRequest},
+		{name: "create wrong type", method: http.MethodPost, path: "/v1/posts",
+			body: `{"title":42,"content":"x"}`, as: "alice", status: http.StatusBadRequest},
+		{name: "create unauthenticated", method: http.MethodPost, path: "/v1/posts",
+			body: `{"title":"Anonymous","content":"x"}`, status: http.StatusUnauthorized},
+
+		{name: "get", method: http.MethodGet, path: "/v1/posts/single?id={alicePost}", status: http.StatusOK},
+		{name: "get with viewer reactions", method: http.MethodGet, path: "/v1/posts/single?id={alicePost}", as: "bob", status: http.StatusOK},
+		{name: "get missing ID", method: http.MethodGet, path: "/v1/posts/single", status: http.StatusBadRequest},
+		{name: "get invalid ID", method: http.MethodGet, path: "/v1/posts/single?id=12345", status: http.StatusBadRequest},
+		{name: "get unknown", method: http.MethodGet, path: "/v1/posts/single?id={unknown}", status: http.StatusNotFound},
+
+		{name: "get with user", method: http.MethodGet, path: "/v1/posts/with-user?id={bobPost}", status: http.StatusOK},
+		{name: "get with user and viewer reactions", method: http.MethodGet, path: "/v1/posts/with-user?id={alicePost}", as: "bob", status: http.StatusOK},
+		{name: "get with user unknown", method: http.MethodGet, path: "/v1/posts/with-user?id={unknown}", status: http.StatusNotFound},
+
+		{name: "list", method: http.MethodGet, path: "/v1/posts", status: http.StatusOK},
+		{name: "list first page", method: http.MethodGet, path: "/v1/posts?limit=1", status: http.StatusOK},
+		{name: "list with viewer reactions", method: http.MethodGet, path: "/v1/posts", as: "bob", status: http.StatusOK},
+		{name: "list invalid limit", method: http.MethodGet, path: "/v1/posts?limit=abc", status: http.StatusBadRequest},
+
+		{name: "by user", method: http.MethodGet, path: "/v1/posts/by-user?user_id={bob}", status: http.StatusOK},
+		{name: "by user with viewer reactions", method: http.MethodGet, path: "/v1/posts/by-user?user_id={alice}", as: "bob", status: http.StatusOK},
+		{name: "by user without posts", method: http.MethodGet, path: "/v1/posts/by-user?user_id={carol}", status: http.StatusOK},
+		{name: "by user invalid ID", method: http.MethodGet, path: "/v1/posts/by-user?user_id=bob", status: http.StatusBadRequest},
+
+		{name: "update", method: http.MethodPatch, path: "/v1/posts/{alicePost}",
+			body: `{"title":"Hello again","tags":["Intro"]}`, as: "alice", status: http.StatusOK},
+		{name: "update other user's post", method: http.MethodPatch, path: "/v1/posts/{bobPost}",
+			body: `{"title":"Mine now"}`, as: "alice", status: http.StatusForbidden},
+		{name: "update unknown", method: http.MethodPatch, path: "/v1/posts/{unknown}",
+			body: `{"title":"Ghost"}`, as: "alice", status: http.StatusNotFound},
+		{name: "update empty title", method: http.MethodPatch, path: "/v1/posts/{alicePost}",
+			body: `{"title":""}`, as: "alice", status: http.StatusBadRequest},
+		{name: "update invalid tag", method: http.MethodPatch, path: "/v1/posts/{alicePost}",
+			body: `{"tags":["ok","not ok"]}`, as: "alice", status: http.StatusBadRequest},
+		{name: "update no fields", method: http.MethodPatch, path: "/v1/posts/{alicePost}",
+			body: `{}`, as: "alice", status: http.StatusBadRequest},
+
+		{name: "delete", method: http.MethodDelete, path: "/v1/posts/{alicePost}", as: "alice", status: http.StatusNoContent},
+		{name: "delete other user's post", method: http.MethodDelete, path: "/v1/posts/{bobPost}", as: "alice", status: http.StatusForbidden},
+		{name: "delete unknown", method: http.MethodDelete, path: "/v1/posts/{unknown}", as: "alice", status: http.StatusNotFound},
+		{name: "delete invalid ID", method: http.MethodDelete, path: "/v1/posts/not-an-id", as: "alice", status: http.StatusBadRequest},
+	})
+}
+
+func TestPostListingPagination(t *testing.T) {
+	env := newTestEnv(t)
+
+	var page struct {
+		Posts      []PostResponse `json:"posts"`
+		NextCursor *string        `json:"next_cursor"`
+	}
+	env.decode(env.do(http.MethodGet, "/v1/posts?limit=1", "", ""), &page)
+	if len(page.Posts) != 1 || page.Posts[0].ID != env.vars["alicePost"] || page.NextCursor == nil {
+		t.Fatalf("first page = %+v, want alicePost and a cursor", page)
+	}
+
+	env.decode(env.do(http.MethodGet, "/v1/posts?limit=1&cursor="+*page.NextCursor, "", ""), &page)
+	if len(page.Posts) != 1 || page.Posts[0].ID != env.vars["bobPost"] {
+		t.Fatalf("second page = %+v, want bobPost", page)
+	}
+	if page.NextCursor != nil {
+		t.Errorf("next_cursor = %q on the last page, want null", *page.NextCursor)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/ratelimit"
+)
+
+// rateLimit returns middleware allowing each client policy.Limit requests
+// per policy.Period. Clients are the authenticated user when the route
+// has one, so it must run after authTokenMiddleware to see it, and the
+// client IP set by realIPMiddleware otherwise. Responses carry the
+// RateLimit-* headers of the IETF draft; rejected ones a Retry-After.
+func (app *application) rateLimit(policy ratelimit.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if app.rateLimiter == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := app.rateLimiter.Take(r.Context(), policy.Name+":"+rateLimitKey(r), policy)
+			if err != nil {
+				// An unreachable limiter shouldn't take the API down with it
+				app.requestLogger(r).Warn("Rate limiter unavailable", "policy", policy.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Period)))
+
+			if !res.Allowed {
+				retryAfter := max(ceilSeconds(res.RetryAfter), 1)
+				h.Set("Retry-After", strconv.Itoa(retryAfter))
+				app.metrics.rateLimited.WithLabelValues(policy.Name).Inc()
+				app.errorResponse(w, r, &apiError{status: http.StatusTooManyRequests, code: codeRateLimited,
+					detail: fmt.Sprintf("Too many requests, retry in %d seconds", retryAfter)})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey identifies the client a request is counted against
+func rateLimitKey(r *http.Request) string {
+	if user := getUserFromContext(r); user != nil {
+		return "user:" + user.ID.String()
+	}
+
+	return "ip:" + clientIP(r)
+}
+
+// ceilSeconds rounds d up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/ratelimit"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newRateLimitedEnv returns a test env limited by limiter, allowing a
+// single signup and post creation
+func newRateLimitedEnv(t *testing.T, limiter ratelimit.Store) *testEnv {
+	t.Helper()
+
+	env := newTestEnv(t)
+	env.app.rateLimiter = limiter
+	env.app.config.rateLimit = rateLimitConfig{
+		api:    ratelimit.Policy{Name: "api", Limit: 100, Period: time.Minute},
+		signup: ratelimit.Policy{Name: "signup", Limit: 1, Period: time.Hour},
+		login:  ratelimit.Policy{Name: "login", Limit: 100, Period: time.Minute},
+		posts:  ratelimit.Policy{Name: "posts", Limit: 1, Period: time.Hour},
+	}
+	env.handler = env.app.mount()
+	return env
+}
+
+func TestRateLimitPerClient(t *testing.T) {
+	env := newRateLimitedEnv(t, ratelimit.NewMemory())
+
+	signup := func(name string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"username":%q,"email":"%s@example.com","password":"password123"}`, name, name)
+		return env.do(http.MethodPost, "/v1/users", body, "")
+	}
+
+	rr := signup("dave")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("first signup status = %d, want %d", rr.Code, http.StatusCreated)
+	}
+	if got := rr.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+	if got := rr.Header().Get("RateLimit-Policy"); got != "1;w=3600" {
+		t.Errorf("RateLimit-Policy = %q, want 1;w=3600", got)
+	}
+
+	rr = signup("erin")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("second signup status = %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+	// The bucket refills a little between the requests
+	if got, _ := strconv.Atoi(rr.Header().Get("Retry-After")); got < 3500 || got > 3600 {
+		t.Errorf("Retry-After = %q, want about an hour", rr.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(rr.Body.String(), `"code":"rate_limited"`) {
+		t.Errorf("body = %s, want a rate_limited problem", rr.Body.String())
+	}
+	if got := testutil.ToFloat64(env.app.metrics.rateLimited.WithLabelValues("signup")); got != 1 {
+		t.Errorf("rate limited counter = %v, want 1", got)
+	}
+
+	// Other routes only count against the per-client limit
+	if rr := env.do(http.MethodGet, "/v1/posts", "", ""); rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "100" {
+		t.Errorf("list posts: status %d, RateLimit-Limit %q; want 200 and 100", rr.Code, rr.Header().Get("RateLimit-Limit"))
+	}
+
+	// Probes are never limited
+	if rr := env.do(http.MethodGet, "/v1/health/live", "", ""); rr.Header().Get("RateLimit-Limit") != "" {
+		t.Error("liveness probe is rate limited")
+	}
+}
+
+func TestRateLimitPerUser(t *testing.T) {
+	env := newRateLimitedEnv(t, ratelimit.NewMemory())
+
+	post := `{"title":"Limited","content":"x"}`
+	if rr := env.do(http.MethodPost, "/v1/posts", post, "alice"); rr.Code != http.StatusCreated {
+		t.Fatalf("alice's first post status = %d, want %d", rr.Code, http.StatusCreated)
+	}
+	if rr := env.do(http.MethodPost, "/v1/posts", post, "alice"); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("alice's second post status = %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+
+	// Requests from the same address by another user have their own bucket
+	if rr := env.do(http.MethodPost, "/v1/posts", post, "bob"); rr.Code != http.StatusCreated {
+		t.Errorf("bob's first post status = %d, want %d", rr.Code, http.StatusCreated)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	env := newRateLimitedEnv(t, failingLimiter{})
+
+	rr := env.do(http.MethodGet, "/v1/posts", "", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if rr.Header().Get("RateLimit-Limit") != "" {
+		t.Error("RateLimit headers set without a limiter result")
+	}
+}
+
+// failingLimiter is a Store whose backend is down
+type failingLimiter struct{}
+
+func (failingLimiter) Take(context.Context, string, ratelimit.Policy) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
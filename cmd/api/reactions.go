@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// ReactionsResponse represents a post's reaction counters and the caller's
+// own reactions after a change
+type ReactionsResponse struct {
+	PostID          string           `json:"post_id"`
+	Reactions       map[string]int64 `json:"reactions"`
+	ViewerReactions []string         `json:"viewer_reactions"`
+}
+
+// addReactionHandler handles PUT /v1/posts/{id}/reactions/{kind}
+// Idempotent: reacting twice with the same kind has no further effect.
+func (app *application) addReactionHandler(w http.ResponseWriter, r *http.Request) {
+	postID, kind, ok := app.readReaction(w, r)
+	if !ok {
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	_, err := app.store.Reactions.Add(r.Context(), postID, user.ID, kind)
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "post"))
+		return
+	}
+
+	app.writeReactionsResponse(w, r, postID)
+}
+
+// removeReactionHandler handles DELETE /v1/posts/{id}/reactions/{kind}
+// Idempotent: removing a reaction that does not exist succeeds.
+func (app *application) removeReactionHandler(w http.ResponseWriter, r *http.Request) {
+	postID, kind, ok := app.readReaction(w, r)
+	if !ok {
+		return
+	}
+
+	user := getUserFromContext(r)
+
+	if _, err := app.store.Reactions.Remove(r.Context(), postID, user.ID, kind); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeReactionsResponse(w, r, postID)
+}
+
+// readReaction parses the {id} and {kind} URL parameters. It writes the
+// error response itself on failure.
+func (app *application) readReaction(w http.ResponseWriter, r *http.Request) (store.ID, string, bool) {
+	postID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid post ID format"))
+		return "", "", false
+	}
+
+	kind := chi.URLParam(r, "kind")
+	if !store.ReactionKinds[kind] {
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "Unknown reaction kind"))
+		return "", "", false
+	}
+
+	return postID, kind, true
+}
+
+// writeReactionsResponse writes the current reactions of a post
+func (app *application) writeReactionsResponse(w http.ResponseWriter, r *http.Request, postID store.ID) {
+	post, err := app.store.Posts.GetByID(r.Context(), postID)
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "post"))
+		return
+	}
+
+	viewerReactions, err := app.viewerReactions(r, postID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, ReactionsResponse{
+		PostID:          post.ID.String(),
+		Reactions:       reactionCounts(post),
+		ViewerReactions: viewerReactions,
+	})
+}
+
+// viewerReactions returns the authenticated caller's reactions on a post,
+// or nil for anonymous requests
+func (app *application) viewerReactions(r *http.Request, postID store.ID) ([]string, error) {
+	if getUserFromContext(r) == nil {
+		return nil, nil
+	}
+
+	kinds, err := app.viewerReactionsByPost(r, []store.ID{postID})
+	if err != nil {
+		return nil, err
+	}
+	return kinds[postID], nil
+}
+
+// viewerReactionsByPost returns the authenticated caller's reactions on
+// each of postIDs with a single lookup, or nil for anonymous requests.
+// Posts the caller didn't react to map to an empty list, so every response
+// to a signed-in caller carries viewer_reactions.
+func (app *application) viewerReactionsByPost(r *http.Request, postIDs []store.ID) (map[store.ID][]string, error) {
+	user := getUserFromContext(r)
+	if user == nil || len(postIDs) == 0 {
+		return nil, nil
+	}
+
+	kinds, err := app.store.Reactions.GetKindsByUser(r.Context(), postIDs, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if kinds == nil {
+		kinds = make(map[store.ID][]string, len(postIDs))
+	}
+	for _, id := range postIDs {
+		if kinds[id] == nil {
+			kinds[id] = []string{}
+		}
+	}
+	return kinds, nil
+}
+
+// postIDs returns the IDs of posts
+func postIDs[P store.Post | store.PostWithUser](posts []P) []store.ID {
+	ids := make([]store.ID, 0, len(posts))
+	for _, post := range posts {
+		switch post := any(post).(type) {
+		case store.Post:
+			ids = append(ids, post.ID)
+		case store.PostWithUser:
+			ids = append(ids, post.ID)
+		}
+	}
+	return ids
+}
+
+// reactionCounts returns the post's non-zero reaction counters
+func reactionCounts(post *store.Post) map[string]int64 {
+	counts := make(map[string]int64, len(post.Reactions))
+	for kind, n := range post.Reactions {
+		if n > 0 {
+			counts[kind] = n
+		}
+	}
+	return counts
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestReactionRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "add", method: http.MethodPut, path: "/v1/posts/{alicePost}/reactions/love", as: "alice", status: http.StatusOK},
+		{name: "add existing", method: http.MethodPut, path: "/v1/posts/{alicePost}/reactions/like", as: "bob", status: http.StatusOK},
+		{name: "add unknown kind", method: http.MethodPut, path: "/v1/posts/{alicePost}/reactions/meh", as: "bob", status: http.StatusBadRequest},
+		{name: "add on unknown post", method: http.MethodPut, path: "/v1/posts/{unknown}/reactions/like", as: "bob", status: http.StatusNotFound},
+		{name: "add unauthenticated", method: http.MethodPut, path: "/v1/posts/{alicePost}/reactions/like", status: http.StatusUnauthorized},
+
+		{name: "remove", method: http.MethodDelete, path: "/v1/posts/{alicePost}/reactions/like", as: "bob", status: http.StatusOK},
+		{name: "remove missing", method: http.MethodDelete, path: "/v1/posts/{alicePost}/reactions/like", as: "carol", status: http.StatusOK},
+		{name: "remove invalid post ID", method: http.MethodDelete, path: "/v1/posts/nope/reactions/like", as: "bob", status: http.StatusBadRequest},
+	})
+}
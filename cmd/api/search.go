@@ -0,0 +1,184 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/search"
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+// snippetLength is the approximate length in characters of content snippets
+const snippetLength = 200
+
+// SearchHighlights holds HTML-escaped fragments with matches wrapped in <mark>
+type SearchHighlights struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// SearchResultResponse represents a post matching a search
+type SearchResultResponse struct {
+	PostResponse
+	Author     AuthorResponse   `json:"author"`
+	Score      float64          `json:"score"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// searchPostsHandler handles GET /v1/search/posts
+//
+// Query parameters:
+//
+//	q          search text; supports "exact phrases" and -excluded words (required)
+//	tags       comma-separated tags posts must all carry
+//	author_id  comma-separated author IDs
+//	from, to   creation date range, RFC 3339 or YYYY-MM-DD (to is inclusive for dates)
+//	sort       relevance (default) or recent
+//	limit      page size, capped like other listings
+//	offset     number of results to skip
+func (app *application) searchPostsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "Search query is required"))
+		return
+	}
+
+	limit, err := readLimit(r)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	params := store.PostSearch{
+		Text:  q,
+		Tags:  normalizeTagList(splitList(query.Get("tags"))),
+		Sort:  store.SortRelevance,
+		Limit: limit,
+	}
+
+	for _, idStr := range splitList(query.Get("author_id")) {
+		id, err := store.ParseID(idStr)
+		if err != nil {
+			app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid author ID format"))
+			return
+		}
+		params.AuthorIDs = append(params.AuthorIDs, id)
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, _, err := parseDate(fromStr)
+		if err != nil {
+			app.errorResponse(w, r, badRequest(codeInvalidRequest, "Invalid from date"))
+			return
+		}
+		params.From = &from
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, dateOnly, err := parseDate(toStr)
+		if err != nil {
+			app.errorResponse(w, r, badRequest(codeInvalidRequest, "Invalid to date"))
+			return
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		params.To = &to
+	}
+
+	switch sort := query.Get("sort"); sort {
+	case "", store.SortRelevance:
+	case store.SortRecent:
+		params.Sort = store.SortRecent
+	default:
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "Sort must be relevance or recent"))
+		return
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || offset < 0 {
+			app.errorResponse(w, r, badRequest(codeInvalidRequest, "offset must be a non-negative integer"))
+			return
+		}
+		params.Offset = offset
+	}
+
+	results, err := app.store.Posts.Search(r.Context(), params)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	ids := make([]store.ID, 0, len(results))
+	for _, res := range results {
+		ids = append(ids, res.Post.ID)
+	}
+	viewerReactions, err := app.viewerReactionsByPost(r, ids)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	terms := search.Terms(q)
+	response := make([]SearchResultResponse, 0, len(results))
+	for _, res := range results {
+		post := res.Post
+		item := SearchResultResponse{
+			PostResponse: newPostResponse(&post),
+			Author:       AuthorResponse{ID: res.User.ID.String(), Username: res.User.Username},
+			Score:        res.Score,
+			Highlights: SearchHighlights{
+				Title:   search.Highlight(post.Title, terms),
+				Content: search.Snippet(post.Content, terms, snippetLength),
+			},
+		}
+		item.ViewerReactions = viewerReactions[post.ID]
+		response = append(response, item)
+	}
+
+	// Only offer another page when this one was full
+	var nextOffset *int64
+	if int64(len(response)) == params.Limit {
+		next := params.Offset + params.Limit
+		nextOffset = &next
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"results":     response,
+		"count":       len(response),
+		"next_offset": nextOffset,
+	})
+}
+
+// splitList splits a comma-separated query parameter, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseDate parses an RFC 3339 timestamp or a YYYY-MM-DD date (as UTC
+// midnight) and reports which form it was
+func parseDate(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t, false, err
+}
+
+// normalizeTagList normalizes tag filters so they match stored tags
+func normalizeTagList(tags []string) []string {
+	for i, tag := range tags {
+		tags[i] = store.NormalizeTag(tag)
+	}
+	return tags
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSearchRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "search", method: http.MethodGet, path: "/v1/search/posts?q=gophers", status: http.StatusOK},
+		{name: "search with viewer reactions", method: http.MethodGet, path: "/v1/search/posts?q=gophers", as: "bob", status: http.StatusOK},
+		{name: "search title and content", method: http.MethodGet, path: "/v1/search/posts?q=go", status: http.StatusOK},
+		{name: "search phrase", method: http.MethodGet, path: "/v1/search/posts?q=%22first+post%22", status: http.StatusOK},
+		{name: "search excluded word", method: http.MethodGet, path: "/v1/search/posts?q=go+-channels", status: http.StatusOK},
+		{name: "search by tag", method: http.MethodGet, path: "/v1/search/posts?q=go&tags=Concurrency", status: http.StatusOK},
+		{name: "search by author", method: http.MethodGet, path: "/v1/search/posts?q=go&author_id={alice}", status: http.StatusOK},
+		{name: "search recent first with paging", method: http.MethodGet, path: "/v1/search/posts?q=go&sort=recent&limit=1", status: http.StatusOK},
+		{name: "search future dates", method: http.MethodGet, path: "/v1/search/posts?q=go&from=2999-01-01", status: http.StatusOK},
+
+		{name: "search missing query", method: http.MethodGet, path: "/v1/search/posts?q=+", status: http.StatusBadRequest},
+		{name: "search invalid sort", method: http.MethodGet, path: "/v1/search/posts?q=go&sort=oldest", status: http.StatusBadRequest},
+		{name: "search invalid author", method: http.MethodGet, path: "/v1/search/posts?q=go&author_id=alice", status: http.StatusBadRequest},
+		{name: "search invalid date", method: http.MethodGet, path: "/v1/search/posts?q=go&to=yesterday", status: http.StatusBadRequest},
+		{name: "search invalid limit", method: http.MethodGet, path: "/v1/search/posts?q=go&limit=0", status: http.StatusBadRequest},
+		{name: "search invalid offset", method: http.MethodGet, path: "/v1/search/posts?q=go&offset=-1", status: http.StatusBadRequest},
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// SessionResponse represents the JSON response for a session (device)
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// getSessionsHandler handles GET /v1/users/me/sessions
+func (app *application) getSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	sessions, err := app.store.Sessions.GetActiveByUserID(r.Context(), user.ID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	currentID := getSessionIDFromContext(r)
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		response = append(response, SessionResponse{
+			ID:         s.ID.String(),
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			Current:    s.ID.String() == currentID,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+		})
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"sessions": response,
+		"count":    len(response),
+	})
+}
+
+// revokeSessionHandler handles DELETE /v1/users/me/sessions/{id}
+func (app *application) revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	sessionID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid session ID format"))
+		return
+	}
+
+	err = app.store.Sessions.Revoke(r.Context(), sessionID, user.ID)
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "session"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSessionRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "list", method: http.MethodGet, path: "/v1/users/me/sessions", as: "alice", status: http.StatusOK},
+		{name: "list unauthenticated", method: http.MethodGet, path: "/v1/users/me/sessions", status: http.StatusUnauthorized},
+
+		{name: "revoke", method: http.MethodDelete, path: "/v1/users/me/sessions/{aliceSession}", as: "alice", status: http.StatusNoContent},
+		{name: "revoke other user's session", method: http.MethodDelete, path: "/v1/users/me/sessions/{bobSession}", as: "alice", status: http.StatusNotFound},
+		{name: "revoke invalid ID", method: http.MethodDelete, path: "/v1/users/me/sessions/not-an-id", as: "alice", status: http.StatusBadRequest},
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 30 * 24 * time.Hour
+	defaultTrendingLimit  = 10
+	maxTrendingLimit      = 50
+)
+
+// getPostsByTagHandler handles GET /v1/tags/{tag}/posts
+func (app *application) getPostsByTagHandler(w http.ResponseWriter, r *http.Request) {
+	tag := store.NormalizeTag(chi.URLParam(r, "tag"))
+	if tag == "" {
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "Tag is required"))
+		return
+	}
+
+	page, err := readPage(r)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	posts, next, err := app.store.Posts.GetByTag(r.Context(), tag, page)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	viewerReactions, err := app.viewerReactionsByPost(r, postIDs(posts))
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"tag":         tag,
+		"posts":       newPostListResponse(posts, viewerReactions),
+		"count":       len(posts),
+		"next_cursor": encodeCursor(next),
+	})
+}
+
+// getTrendingTagsHandler handles GET /v1/tags/trending?window=24h&limit=10
+func (app *application) getTrendingTagsHandler(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		d, err := time.ParseDuration(windowStr)
+		if err != nil || d < time.Hour || d > maxTrendingWindow {
+			app.errorResponse(w, r, badRequest(codeInvalidRequest, "Window must be a duration between 1h and 720h"))
+			return
+		}
+		// Whole hours keep the number of distinct cache entries small
+		window = d.Round(time.Hour)
+	}
+
+	limit := int64(defaultTrendingLimit)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || l < 1 {
+			app.errorResponse(w, r, badRequest(codeInvalidRequest, "limit must be a positive integer"))
+			return
+		}
+		limit = min(l, maxTrendingLimit)
+	}
+
+	tags, err := app.store.Tags.GetTrending(r.Context(), window, limit)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"window": window.String(),
+		"tags":   tags,
+		"count":  len(tags),
+	})
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTagRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "trending", method: http.MethodGet, path: "/v1/tags/trending", status: http.StatusOK},
+		{name: "trending limited", method: http.MethodGet, path: "/v1/tags/trending?window=1h&limit=1", status: http.StatusOK},
+		{name: "trending window rounded", method: http.MethodGet, path: "/v1/tags/trending?window=90m30s", status: http.StatusOK},
+		{name: "trending window too short", method: http.MethodGet, path: "/v1/tags/trending?window=1m", status: http.StatusBadRequest},
+		{name: "trending invalid limit", method: http.MethodGet, path: "/v1/tags/trending?limit=-1", status: http.StatusBadRequest},
+
+		{name: "posts", method: http.MethodGet, path: "/v1/tags/go/posts", status: http.StatusOK},
+		{name: "posts with viewer reactions", method: http.MethodGet, path: "/v1/tags/go/posts", as: "bob", status: http.StatusOK},
+		{name: "posts tag is normalized", method: http.MethodGet, path: "/v1/tags/%23Intro/posts", status: http.StatusOK},
+		{name: "posts unused tag", method: http.MethodGet, path: "/v1/tags/rust/posts", status: http.StatusOK},
+	})
+}
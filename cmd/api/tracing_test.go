@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	env := newTestEnv(t)
+	env.app.tracer = tp.Tracer(tracerName)
+	env.app.store = store.Trace(env.app.store, tp)
+	env.handler = env.app.mount()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, env.expand("/v1/posts/single?id={alicePost}"), nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	rr := httptest.NewRecorder()
+	env.handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	var server sdktrace.ReadOnlySpan
+	var children []string
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() == trace.SpanKindServer {
+			server = span
+		}
+	}
+	if server == nil {
+		t.Fatal("no server span")
+	}
+	if server.Name() != "GET /v1/posts/single" {
+		t.Errorf("server span name = %q, want the route pattern", server.Name())
+	}
+	if got := server.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("trace ID = %s, want the incoming %s", got, traceID)
+	}
+	attrs := attribute.NewSet(server.Attributes()...)
+	for key, want := range map[attribute.Key]string{"http.route": "/v1/posts/single", "http.request.id": "req-1"} {
+		if got, _ := attrs.Value(key); got.AsString() != want {
+			t.Errorf("%s = %q, want %q", key, got.AsString(), want)
+		}
+	}
+
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() == server.SpanContext().SpanID() {
+			children = append(children, span.Name())
+		}
+	}
+	if len(children) == 0 || children[0] != "posts.GetByID" {
+		t.Errorf("server span children = %v, want store spans starting with posts.GetByID", children)
+	}
+}
@@ -1,19 +1,40 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/Nutan-Kum12/Gopherso/internal/auth"
+	"github.com/Nutan-Kum12/Gopherso/internal/mailer"
 	"github.com/Nutan-Kum12/Gopherso/internal/store"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/go-chi/chi/v5"
 )
 
 // CreateUserRequest represents the JSON payload for creating a user
 type CreateUserRequest struct {
-	Username string `json:"username" validate:"required,min=3,max=20"`
+	Username string `json:"username" validate:"required,min=3,max=20,username"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required,min=6,max=72"`
+}
+
+// UpdateUserRequest represents the JSON payload for updating a profile.
+// Omitted fields are left unchanged.
+type UpdateUserRequest struct {
+	Username *string `json:"username" validate:"omitnil,min=3,max=20,username"`
+	Bio      *string `json:"bio" validate:"omitempty,max=500"`
+}
+
+// ChangeEmailRequest represents the JSON payload for requesting an email change
+type ChangeEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ConfirmEmailRequest represents the JSON payload for confirming an email change
+type ConfirmEmailRequest struct {
+	Token string `json:"token" validate:"required"`
 }
 
 // UserResponse represents the JSON response for user data
@@ -21,38 +42,23 @@ type UserResponse struct {
 	ID        string    `json:"id"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
+	Bio       string    `json:"bio"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// UserWithPostsResponse represents a user with one page of their posts
+type UserWithPostsResponse struct {
+	*store.UserWithPosts
+	NextCursor *string `json:"next_cursor"`
+}
+
 // createUserHandler handles POST /v1/users
 func (app *application) createUserHandler(w http.ResponseWriter, r *http.Request) {
 	var req CreateUserRequest
 
-	// Decode JSON request
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
-		return
-	}
-
-	// Basic validation
-	if req.Username == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Username is required")
-		return
-	}
-	if req.Email == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Email is required")
-		return
-	}
-	if req.Password == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Password is required")
-		return
-	}
-
-	// Check if user already exists
-	existingUser, err := app.store.Users.GetByEmail(r.Context(), req.Email)
-	if err == nil && existingUser != nil {
-		app.writeErrorResponse(w, http.StatusConflict, "User with this email already exists")
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
@@ -60,19 +66,24 @@ func (app *application) createUserHandler(w http.ResponseWriter, r *http.Request
 	user := &store.User{
 		Username: req.Username,
 		Email:    req.Email,
-		Password: req.Password, // In production, hash this password!
+	}
+	if err := user.SetPassword(req.Password); err != nil {
+		app.errorResponse(w, r, err)
+		return
 	}
 
+	// Uniqueness is enforced by the store, not a racy pre-read
 	if err := app.store.Users.Create(r.Context(), user); err != nil {
-		app.writeErrorResponse(w, http.StatusInternalServerError, "Failed to create user")
+		app.errorResponse(w, r, storeError(err, "user"))
 		return
 	}
 
 	// Return user response
 	response := UserResponse{
-		ID:        user.ID.Hex(),
+		ID:        user.ID.String(),
 		Username:  user.Username,
 		Email:     user.Email,
+		Bio:       user.Bio,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
@@ -85,26 +96,27 @@ func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from URL parameter (we'll implement this with chi URL params)
 	userIDStr := r.URL.Query().Get("id")
 	if userIDStr == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "User ID is required")
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "User ID is required"))
 		return
 	}
 
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	userID, err := store.ParseID(userIDStr)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID format")
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid user ID format"))
 		return
 	}
 
 	user, err := app.store.Users.GetByID(r.Context(), userID)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusNotFound, "User not found")
+		app.errorResponse(w, r, storeError(err, "user"))
 		return
 	}
 
 	response := UserResponse{
-		ID:        user.ID.Hex(),
+		ID:        user.ID.String(),
 		Username:  user.Username,
 		Email:     user.Email,
+		Bio:       user.Bio,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
@@ -116,21 +128,196 @@ func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
 func (app *application) getUserWithPostsHandler(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.URL.Query().Get("id")
 	if userIDStr == "" {
-		app.writeErrorResponse(w, http.StatusBadRequest, "User ID is required")
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "User ID is required"))
+		return
+	}
+
+	userID, err := store.ParseID(userIDStr)
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid user ID format"))
+		return
+	}
+
+	page, err := readPage(r)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	userWithPosts, next, err := app.store.Users.GetWithPosts(r.Context(), userID, page)
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "user"))
+		return
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, UserWithPostsResponse{
+		UserWithPosts: userWithPosts,
+		NextCursor:    encodeCursor(next),
+	})
+}
+
+// updateUserHandler handles PATCH /v1/users/{id}
+func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := app.requireSelf(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	changes := store.UserUpdate{Username: req.Username, Bio: req.Bio}
+	if changes == (store.UserUpdate{}) {
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "No fields to update"))
+		return
+	}
+
+	err := app.store.Users.Update(r.Context(), userID, changes)
+	if err != nil {
+		app.errorResponse(w, r, storeError(err, "user"))
+		return
+	}
+
+	user, err := app.store.Users.GetByID(r.Context(), userID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	response := UserResponse{
+		ID:        user.ID.String(),
+		Username:  user.Username,
+		Email:     user.Email,
+		Bio:       user.Bio,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// requestEmailChangeHandler handles POST /v1/users/{id}/email
+// The new address only takes effect once the token mailed to it is confirmed.
+func (app *application) requestEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := app.requireSelf(w, r)
+	if !ok {
+		return
+	}
+
+	var req ChangeEmailRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	// Email addresses are matched case-insensitively
+	if strings.EqualFold(req.Email, getUserFromContext(r).Email) {
+		app.errorResponse(w, r, badRequest(codeInvalidRequest, "New email must differ from the current one"))
+		return
+	}
+
+	_, err := app.store.Users.GetByEmail(r.Context(), req.Email)
+	if err == nil {
+		err = &store.DuplicateError{Field: "email"}
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		app.errorResponse(w, r, storeError(err, "user"))
+		return
+	}
+
+	token, tokenHash, err := auth.NewOpaqueToken()
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(app.config.mail.emailChangeExp)
+	err = app.store.Users.RequestEmailChange(r.Context(), userID, req.Email, tokenHash, expiresAt)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	msg := mailer.Message{
+		From:    app.config.mail.from,
+		To:      req.Email,
+		Subject: "Confirm your new Gopherso email address",
+		Body: fmt.Sprintf("Use the link below to confirm your new email address. It expires at %s.\n\n%s/confirm-email?token=%s\n",
+			expiresAt.UTC().Format(time.RFC1123), app.config.frontendURL, token),
+	}
+	if err := app.mailer.Send(r.Context(), msg); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writeSuccessResponse(w, http.StatusAccepted, "Confirmation email sent", nil)
+}
+
+// confirmEmailChangeHandler handles POST /v1/users/email/confirm
+func (app *application) confirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmEmailRequest
+
+	if err := app.readJSON(w, r, &req); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	user, err := app.store.Users.ConfirmEmailChange(r.Context(), auth.HashToken(req.Token))
+	if errors.Is(err, store.ErrConflict) {
+		err = &store.DuplicateError{Field: "email"}
+	}
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID format")
+		app.errorResponse(w, r, storeError(err, "user"))
 		return
 	}
 
-	userWithPosts, err := app.store.Users.GetWithPosts(r.Context(), userID)
+	response := UserResponse{
+		ID:        user.ID.String(),
+		Username:  user.Username,
+		Email:     user.Email,
+		Bio:       user.Bio,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+
+	app.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// deleteUserHandler handles DELETE /v1/users/{id}
+// The user's posts and sessions are deleted along with the account.
+func (app *application) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := app.requireSelf(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.store.Users.Delete(r.Context(), userID)
 	if err != nil {
-		app.writeErrorResponse(w, http.StatusNotFound, "User not found")
+		app.errorResponse(w, r, storeError(err, "user"))
 		return
 	}
 
-	app.writeJSONResponse(w, http.StatusOK, userWithPosts)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireSelf parses the {id} URL parameter and ensures it is the
+// authenticated user. It writes the error response itself when not.
+func (app *application) requireSelf(w http.ResponseWriter, r *http.Request) (store.ID, bool) {
+	userID, err := store.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorResponse(w, r, badRequest(codeInvalidID, "Invalid user ID format"))
+		return "", false
+	}
+
+	if getUserFromContext(r).ID != userID {
+		app.errorResponse(w, r, forbidden("You can only modify your own account"))
+		return "", false
+	}
+
+	return userID, true
 }
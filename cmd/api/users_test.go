@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUserRoutes(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "create", method: http.MethodPost, path: "/v1/users",
+			body: `{"username":"dave","email":"dave@example.com","password":"password123"}`, status: http.StatusCreated},
+		{name: "create duplicate email", method: http.MethodPost, path: "/v1/users",
+			body: `{"username":"dave","email":"ALICE@example.com","password":"password123"}`, status: http.StatusConflict},
+		{name: "create duplicate username", method: http.MethodPost, path: "/v1/users",
+			body: `{"username":"alice","email":"dave@example.com","password":"password123"}`, status: http.StatusConflict},
+		{name: "create missing username", method: http.MethodPost, path: "/v1/users",
+			body: `{"email":"dave@example.com","password":"password123"}`, status: http.StatusBadRequest},
+		{name: "create invalid JSON", method: http.MethodPost, path: "/v1/users",
+			body: `[]`, status: http.StatusBadRequest},
+		{name: "create invalid fields", method: http.MethodPost, path: "/v1/users",
+			body: `{"username":"d@ve","email":"not-an-email","password":"short"}`, status: http.StatusBadRequest},
+		{name: "create password too long", method: http.MethodPost, path: "/v1/users",
+			body: `{"username":"dave","email":"dave@example.com","password":"` + strings.Repeat("a", 73) + `"}`, status: http.StatusBadRequest},
+		{name: "create password over 72 bytes", method: http.MethodPost, path: "/v1/users",
+			body: `{"username":"dave","email":"dave@example.com","password":"` + strings.Repeat("é", 40) + `"}`, status: http.StatusBadRequest},
+		{name: "create unknown field", method: http.MethodPost, path: "/v1/users",
+			body: `{"username":"dave","email":"dave@example.com","password":"password123","admin":true}`, status: http.StatusBadRequest},
+
+		{name: "get", method: http.MethodGet, path: "/v1/users?id={alice}", status: http.StatusOK},
+		{name: "get missing ID", method: http.MethodGet, path: "/v1/users", status: http.StatusBadRequest},
+		{name: "get invalid ID", method: http.MethodGet, path: "/v1/users?id=not-an-id", status: http.StatusBadRequest},
+		{name: "get unknown", method: http.MethodGet, path: "/v1/users?id={unknown}", status: http.StatusNotFound},
+
+		{name: "get with posts", method: http.MethodGet, path: "/v1/users/posts?id={alice}", status: http.StatusOK},
+		{name: "get with posts invalid cursor", method: http.MethodGet, path: "/v1/users/posts?id={alice}&cursor=nope", status: http.StatusBadRequest},
+		{name: "get with posts unknown", method: http.MethodGet, path: "/v1/users/posts?id={unknown}", status: http.StatusNotFound},
+
+		{name: "update", method: http.MethodPatch, path: "/v1/users/{alice}",
+			body: `{"username":"alice2","bio":"Still a gopher"}`, as: "alice", status: http.StatusOK},
+		{name: "update other user", method: http.MethodPatch, path: "/v1/users/{bob}",
+			body: `{"bio":"hacked"}`, as: "alice", status: http.StatusForbidden},
+		{name: "update duplicate username", method: http.MethodPatch, path: "/v1/users/{alice}",
+			body: `{"username":"Bob"}`, as: "alice", status: http.StatusConflict},
+		{name: "update no fields", method: http.MethodPatch, path: "/v1/users/{alice}",
+			body: `{}`, as: "alice", status: http.StatusBadRequest},
+		{name: "update short username", method: http.MethodPatch, path: "/v1/users/{alice}",
+			body: `{"username":"al"}`, as: "alice", status: http.StatusBadRequest},
+		{name: "update invalid ID", method: http.MethodPatch, path: "/v1/users/not-an-id",
+			body: `{"bio":"x"}`, as: "alice", status: http.StatusBadRequest},
+		{name: "update unauthenticated", method: http.MethodPatch, path: "/v1/users/{alice}",
+			body: `{"bio":"x"}`, status: http.StatusUnauthorized},
+
+		{name: "request email change", method: http.MethodPost, path: "/v1/users/{alice}/email",
+			body: `{"email":"alice@example.org"}`, as: "alice", status: http.StatusAccepted},
+		{name: "request email change to taken address", method: http.MethodPost, path: "/v1/users/{alice}/email",
+			body: `{"email":"carol@example.com"}`, as: "alice", status: http.StatusConflict},
+		{name: "request email change to same address", method: http.MethodPost, path: "/v1/users/{alice}/email",
+			body: `{"email":"alice@example.com"}`, as: "alice", status: http.StatusBadRequest},
+		{name: "request email change to same address in other case", method: http.MethodPost, path: "/v1/users/{alice}/email",
+			body: `{"email":"Alice@Example.com"}`, as: "alice", status: http.StatusBadRequest},
+		{name: "request email change for other user", method: http.MethodPost, path: "/v1/users/{bob}/email",
+			body: `{"email":"bob@example.org"}`, as: "alice", status: http.StatusForbidden},
+
+		{name: "confirm email change", method: http.MethodPost, path: "/v1/users/email/confirm",
+			body: `{"token":"{emailToken}"}`, status: http.StatusOK},
+		{name: "confirm email change invalid token", method: http.MethodPost, path: "/v1/users/email/confirm",
+			body: `{"token":"nope"}`, status: http.StatusBadRequest},
+		{name: "confirm email change missing token", method: http.MethodPost, path: "/v1/users/email/confirm",
+			body: `{}`, status: http.StatusBadRequest},
+
+		{name: "delete", method: http.MethodDelete, path: "/v1/users/{alice}", as: "alice", status: http.StatusNoContent},
+		{name: "delete other user", method: http.MethodDelete, path: "/v1/users/{bob}", as: "alice", status: http.StatusForbidden},
+	})
+}
+
+func TestRequestEmailChangeSendsConfirmation(t *testing.T) {
+	env := newTestEnv(t)
+
+	rr := env.do(http.MethodPost, "/v1/users/{alice}/email", `{"email":"alice@example.org"}`, "alice")
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusAccepted)
+	}
+
+	if len(env.mailer.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(env.mailer.sent))
+	}
+	msg := env.mailer.sent[0]
+	if msg.To != "alice@example.org" {
+		t.Errorf("To = %q, want the new address", msg.To)
+	}
+
+	_, token, ok := strings.Cut(msg.Body, "/confirm-email?token=")
+	if !ok {
+		t.Fatalf("no confirmation link in %q", msg.Body)
+	}
+	rr = env.do(http.MethodPost, "/v1/users/email/confirm", `{"token":"`+strings.TrimSpace(token)+`"}`, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("confirm: status = %d, want %d; body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	var user UserResponse
+	env.decode(rr, &user)
+	if user.Email != "alice@example.org" {
+		t.Errorf("Email = %q after confirming, want the new address", user.Email)
+	}
+}
+
+func TestDeleteUserRemovesContent(t *testing.T) {
+	env := newTestEnv(t)
+
+	if rr := env.do(http.MethodDelete, "/v1/users/{bob}", "", "bob"); rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+
+	if rr := env.do(http.MethodGet, "/v1/posts/single?id={bobPost}", "", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("bob's post: status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+
+	// bob's comment stays, anonymized, and his reaction is gone
+	rr := env.do(http.MethodGet, "/v1/posts/{alicePost}/comments", "", "")
+	var comments struct {
+		Comments []CommentResponse `json:"comments"`
+	}
+	env.decode(rr, &comments)
+	if len(comments.Comments) != 1 || comments.Comments[0].Author != nil || comments.Comments[0].Content != "" {
+		t.Errorf("comments after delete = %+v, want one anonymized comment", comments.Comments)
+	}
+
+	rr = env.do(http.MethodGet, "/v1/posts/single?id={alicePost}", "", "")
+	var post PostResponse
+	env.decode(rr, &post)
+	if len(post.Reactions) != 0 {
+		t.Errorf("reactions after delete = %v, want none", post.Reactions)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/go-playground/validator/v10"
+)
+
+// maxBodyBytes is the largest request body readJSON accepts
+const maxBodyBytes = 1 << 20
+
+// validUsername matches usernames: ASCII letters, digits and underscores
+var validUsername = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// validate enforces the validate struct tags of request payloads. Field
+// errors are reported under the field's JSON name.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// Tags are checked in the form they are stored in; blank ones are dropped
+	must(v.RegisterValidation("tag", func(fl validator.FieldLevel) bool {
+		tag := store.NormalizeTag(fl.Field().String())
+		return tag == "" || store.IsValidTag(tag)
+	}))
+	must(v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return validUsername.MatchString(fl.Field().String())
+	}))
+
+	return v
+}
+
+// must panics on errors that can only be programming mistakes
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// readJSON decodes the request body, a single JSON object of at most
+// maxBodyBytes without unknown fields, into dst and validates it. The
+// returned error is an *apiError ready for errorResponse.
+func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return badRequest(codeInvalidJSON, "Request body must contain a single JSON object")
+	}
+
+	return validateRequest(dst)
+}
+
+// decodeError maps a json.Decoder error to the problem reported for it
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &apiError{status: http.StatusRequestEntityTooLarge, code: codeBodyTooLarge,
+			detail: fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesErr.Limit)}
+	case errors.Is(err, io.EOF):
+		return badRequest(codeInvalidJSON, "Request body must not be empty")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return badRequest(codeInvalidJSON, "Invalid JSON payload")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return validationFailed(map[string]string{typeErr.Field: "must be " + jsonType(typeErr.Type)})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for this one
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return validationFailed(map[string]string{field: "is not a known field"})
+	default:
+		return badRequest(codeInvalidJSON, "Invalid JSON payload")
+	}
+}
+
+// validateRequest checks v against its validate tags and reports every
+// failing field at once
+func validateRequest(v any) error {
+	err := validate.Struct(v)
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err // nil, or a request type validate can't handle
+	}
+
+	fields := make(map[string]string, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		// Drop the request type from e.g. CreatePostRequest.tags[2]
+		_, path, _ := strings.Cut(fe.Namespace(), ".")
+		fields[path] = fieldMessage(fe)
+	}
+	return validationFailed(fields)
+}
+
+// validationFailed reports field errors keyed by JSON field path
+func validationFailed(fields map[string]string) *apiError {
+	return &apiError{status: http.StatusBadRequest, code: codeValidation,
+		detail: "Request validation failed", fields: fields}
+}
+
+// fieldMessage describes a failed validate tag for clients
+func fieldMessage(fe validator.FieldError) string {
+	unit := "characters"
+	if fe.Kind() == reflect.Slice {
+		unit = "items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "username":
+		return "may only contain letters, digits and underscores"
+	case "tag":
+		return "must be up to 30 letters, digits, '-' or '_'"
+	case "min":
+		if fe.Param() == "1" && unit == "characters" {
+			return "cannot be empty"
+		}
+		return fmt.Sprintf("must be at least %s %s", fe.Param(), unit)
+	case "max":
+		return fmt.Sprintf("must be at most %s %s", fe.Param(), unit)
+	default:
+		return "is invalid"
+	}
+}
+
+// jsonType names the JSON type that decodes into t
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
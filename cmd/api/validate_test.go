@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadJSON(t *testing.T) {
+	type payload struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"valid", `{"name":"gopher"}`, 0, ""},
+		{"empty body", ``, http.StatusBadRequest, codeInvalidJSON},
+		{"malformed", `{"name":`, http.StatusBadRequest, codeInvalidJSON},
+		{"trailing data", `{"name":"a"} {"name":"b"}`, http.StatusBadRequest, codeInvalidJSON},
+		{"unknown field", `{"name":"a","extra":1}`, http.StatusBadRequest, codeValidation},
+		{"too large", `{"name":"` + strings.Repeat("x", maxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, codeBodyTooLarge},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := &application{}
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+
+			var dst payload
+			err := app.readJSON(httptest.NewRecorder(), r, &dst)
+			if tc.status == 0 {
+				if err != nil {
+					t.Fatalf("readJSON = %v, want nil", err)
+				}
+				return
+			}
+
+			apiErr, ok := err.(*apiError)
+			if !ok {
+				t.Fatalf("readJSON = %v, want *apiError", err)
+			}
+			if apiErr.status != tc.status || apiErr.code != tc.code {
+				t.Errorf("readJSON = %d %s, want %d %s", apiErr.status, apiErr.code, tc.status, tc.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import "runtime/debug"
+
+// Set at build time, e.g.
+// go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD)"
+var (
+	version = "dev"
+	commit  = ""
+)
+
+// buildInfo identifies the running build in health responses
+type buildInfo struct {
+	version string
+	commit  string
+}
+
+// currentBuild returns the linked version and commit, falling back to the
+// VCS revision Go stamps into binaries built from a checkout
+func currentBuild() buildInfo {
+	b := buildInfo{version: version, commit: commit}
+	if b.commit != "" {
+		return b
+	}
+
+	b.commit = "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				b.commit = s.Value
+			}
+		}
+	}
+	return b
+}
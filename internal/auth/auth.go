@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned when a token is malformed, expired or signed
+// with the wrong key
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the claims carried by an access token. The subject is the
+// user's ID in hex form and SessionID the session the token was issued for.
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+}
+
+// Authenticator issues and validates access tokens
+type Authenticator interface {
+	GenerateToken(subject, sessionID string) (token string, expiresAt time.Time, err error)
+	ValidateToken(token string) (*Claims, error)
+}
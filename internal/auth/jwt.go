@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTAuthenticator issues HS256-signed JWTs
+type JWTAuthenticator struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+}
+
+func NewJWTAuthenticator(secret, issuer string, ttl time.Duration) *JWTAuthenticator {
+	return &JWTAuthenticator{
+		secret: []byte(secret),
+		issuer: issuer,
+		ttl:    ttl,
+	}
+}
+
+// GenerateToken returns a signed token for subject and its expiry time
+func (a *JWTAuthenticator) GenerateToken(subject, sessionID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(a.ttl)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    a.issuer,
+			Audience:  jwt.ClaimStrings{a.issuer},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		SessionID: sessionID,
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ValidateToken verifies the signature and registered claims of token
+func (a *JWTAuthenticator) ValidateToken(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims,
+		func(t *jwt.Token) (interface{}, error) {
+			return a.secret, nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(a.issuer),
+		jwt.WithAudience(a.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -0,0 +1,29 @@
+package auth
+
+import "strings"
+
+// Refresh tokens are opaque to clients and have the form
+//
+//	<session id>.<random secret>
+//
+// Only the SHA-256 of the whole token is stored server-side.
+
+// NewRefreshToken returns a fresh refresh token for sessionID and its hash
+func NewRefreshToken(sessionID string) (token, hash string, err error) {
+	secret, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	token = sessionID + "." + secret
+	return token, HashToken(token), nil
+}
+
+// ParseRefreshToken extracts the session ID from token and returns it along
+// with the token's hash
+func ParseRefreshToken(token string) (sessionID, hash string, err error) {
+	sessionID, secret, ok := strings.Cut(token, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return "", "", ErrInvalidToken
+	}
+	return sessionID, HashToken(token), nil
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewOpaqueToken returns a random URL-safe token and its hash. Only the
+// hash should be persisted.
+func NewOpaqueToken() (token, hash string, err error) {
+	secret, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	return secret, HashToken(secret), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 of token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns 256 random bits encoded as unpadded base64url
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"time"
 
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func New(uri string, maxPoolSize, minPoolSize uint64, maxIdleTime string) (*mongo.Client, error) {
+// New connects to MongoDB at uri. If monitor is not nil it observes the
+// client's connection pool and commands.
+func New(uri string, maxPoolSize, minPoolSize uint64, maxIdleTime string, monitor *MongoMonitor) (*mongo.Client, error) {
 	// Parse idle time duration
 	duration, err := time.ParseDuration(maxIdleTime)
 	if err != nil {
@@ -21,6 +24,11 @@ func New(uri string, maxPoolSize, minPoolSize uint64, maxIdleTime string) (*mong
 		SetMaxPoolSize(maxPoolSize).
 		SetMinPoolSize(minPoolSize).
 		SetMaxConnIdleTime(duration)
+	if monitor != nil {
+		clientOptions.
+			SetPoolMonitor(&event.PoolMonitor{Event: monitor.poolEvent}).
+			SetMonitor(monitor.commandMonitor())
+	}
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
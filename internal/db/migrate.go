@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrUnknownMigration is returned when migrating to or rolling back a
+	// version this build has no migration for
+	ErrUnknownMigration = errors.New("unknown migration version")
+	// ErrIrreversibleMigration is returned when rolling back a migration
+	// that has no down step
+	ErrIrreversibleMigration = errors.New("migration cannot be rolled back")
+)
+
+// MigrationStatus describes a migration known to this build or recorded
+// as applied in the database
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time // nil while pending
+	Known       bool       // false if applied by a newer build
+}
+
+// migrationInfo describes a migration a driver can run
+type migrationInfo struct {
+	version     int
+	description string
+	reversible  bool
+}
+
+// migrationDriver runs migrations against one database backend
+type migrationDriver interface {
+	// migrations lists the known migrations in ascending version order
+	migrations() []migrationInfo
+	// lock blocks until this process holds the migration lock or ctx is done
+	lock(ctx context.Context) (unlock func(), err error)
+	// applied returns the applied versions and when they were applied
+	applied(ctx context.Context) (map[int]time.Time, error)
+	// up applies a migration and records it. A migration whose step fails
+	// must not be recorded, so that running again retries it.
+	up(ctx context.Context, version int) error
+	// down reverts a migration and removes its record
+	down(ctx context.Context, version int) error
+}
+
+// Migrator applies and rolls back versioned schema migrations. Changes are
+// made while holding a lock in the database, so API replicas starting
+// together apply each migration once.
+type Migrator struct {
+	driver migrationDriver
+}
+
+// Status lists every known or applied migration in version order
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.driver.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, info := range m.driver.migrations() {
+		status := MigrationStatus{Version: info.version, Description: info.description, Known: true}
+		if at, ok := applied[info.version]; ok {
+			status.AppliedAt = &at
+			delete(applied, info.version)
+		}
+		statuses = append(statuses, status)
+	}
+	for version, at := range applied {
+		statuses = append(statuses, MigrationStatus{Version: version, AppliedAt: &at})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Up applies every pending migration. It stops at the first one that
+// fails, leaving it pending so the next run retries it once the cause is
+// fixed; migrations are written to be safe to run again after a failure.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, func(applied map[int]time.Time) (down, up []int, err error) {
+		return nil, m.pending(applied, -1), nil
+	})
+}
+
+// Down rolls back the most recently applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.run(ctx, func(applied map[int]time.Time) (down, up []int, err error) {
+		latest := 0
+		for version := range applied {
+			latest = max(latest, version)
+		}
+		if latest == 0 {
+			return nil, nil, nil
+		}
+		return []int{latest}, nil, nil
+	})
+}
+
+// To migrates up or down so that exactly the migrations up to and
+// including version are applied. Version 0 rolls back everything.
+func (m *Migrator) To(ctx context.Context, version int) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("%w: %d", ErrUnknownMigration, version)
+	}
+
+	return m.run(ctx, func(applied map[int]time.Time) (down, up []int, err error) {
+		for v := range applied {
+			if v > version {
+				down = append(down, v)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(down)))
+		return down, m.pending(applied, version), nil
+	})
+}
+
+// pending returns the known migrations up to version that are not applied,
+// in order. A negative version means all of them.
+func (m *Migrator) pending(applied map[int]time.Time, version int) []int {
+	var versions []int
+	for _, info := range m.driver.migrations() {
+		if version >= 0 && info.version > version {
+			break
+		}
+		if _, ok := applied[info.version]; !ok {
+			versions = append(versions, info.version)
+		}
+	}
+	return versions
+}
+
+// known reports whether this build has a migration with version
+func (m *Migrator) known(version int) bool {
+	_, ok := m.info(version)
+	return ok
+}
+
+func (m *Migrator) info(version int) (migrationInfo, bool) {
+	for _, info := range m.driver.migrations() {
+		if info.version == version {
+			return info, true
+		}
+	}
+	return migrationInfo{}, false
+}
+
+// run takes the migration lock, plans which migrations to roll back and
+// apply given the applied ones, and executes the plan. Rollbacks run first.
+func (m *Migrator) run(ctx context.Context, plan func(applied map[int]time.Time) (down, up []int, err error)) error {
+	unlock, err := m.driver.lock(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	defer unlock()
+
+	applied, err := m.driver.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	down, up, err := plan(applied)
+	if err != nil {
+		return err
+	}
+
+	// Check the whole plan before changing anything
+	for _, version := range down {
+		info, ok := m.info(version)
+		if !ok {
+			return fmt.Errorf("%w: %d was applied by a newer build", ErrUnknownMigration, version)
+		}
+		if !info.reversible {
+			return fmt.Errorf("%w: %d %s", ErrIrreversibleMigration, version, info.description)
+		}
+	}
+
+	for _, version := range down {
+		info, _ := m.info(version)
+		if err := m.driver.down(ctx, version); err != nil {
+			return fmt.Errorf("rolling back migration %d %s: %w", version, info.description, err)
+		}
+		slog.Info("Rolled back migration", "version", version, "description", info.description)
+	}
+
+	for _, version := range up {
+		info, _ := m.info(version)
+		if err := m.driver.up(ctx, version); err != nil {
+			return fmt.Errorf("applying migration %d %s: %w", version, info.description, err)
+		}
+		slog.Info("Applied migration", "version", version, "description", info.description)
+	}
+
+	return nil
+}
@@ -0,0 +1,479 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// migrationsCollection records applied migrations, one document per version
+	migrationsCollection = "schema_migrations"
+	// migrationLockCollection holds the lease of the process migrating
+	migrationLockCollection = "schema_migrations_lock"
+	// migrationLockTTL is how long a lease outlives its last renewal, so a
+	// crashed migrator blocks others for at most this long
+	migrationLockTTL = time.Minute
+)
+
+// mongoMigration is a versioned change to the Mongo schema. A migration is
+// recorded after it runs, so a crash in between runs it again on the next
+// attempt: up and down must be safe to repeat. A nil down makes the
+// migration irreversible.
+type mongoMigration struct {
+	version     int
+	description string
+	up, down    func(ctx context.Context, db *mongo.Database) error
+}
+
+// mongoMigrations is the schema history of the Mongo backend in version
+// order. Released migrations must never be edited or renumbered; change the
+// schema by appending a new one.
+var mongoMigrations = []mongoMigration{
+	{version: 1, description: "create collections", up: createCollections},
+	{version: 2, description: "create indexes", up: createIndexes, down: dropIndexes},
+	{version: 3, description: "expire rate limit buckets", up: createRateLimitIndex, down: dropRateLimitIndex},
+	{version: 4, description: "normalize post tags", up: normalizePostTags},
+}
+
+// mongoCollections are the collections created by migration 1
+var mongoCollections = []string{"users", "posts", "sessions", "follows", "timelines", "comments", "reactions"}
+
+// caseInsensitive collates emails and usernames so they are unique
+// regardless of case
+var caseInsensitive = &options.Collation{Locale: "en", Strength: 2}
+
+// mongoIndexes are the secondary indexes created by migration 2. Names are
+// left to the server's default where the original indexes had none, so
+// databases set up before migrations existed are already up to date.
+var mongoIndexes = []struct {
+	collection string
+	indexes    []mongo.IndexModel
+}{
+	{"users", []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().
+				SetName("users_email_unique").
+				SetUnique(true).
+				SetCollation(caseInsensitive),
+		},
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().
+				SetName("users_username_unique").
+				SetUnique(true).
+				SetCollation(caseInsensitive),
+		},
+	}},
+	{"posts", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		// Cursor pagination sorts on (created_at, _id)
+		{Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		// Tag listings page by (created_at, _id); tags is an array so this
+		// is a multikey index
+		{Keys: bson.D{{Key: "tags", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		// Full-text search, title matches weigh more than content matches
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "content", Value: "text"}},
+			Options: options.Index().
+				SetName("posts_text").
+				SetWeights(bson.M{"title": 3, "content": 1}),
+		},
+	}},
+	{"sessions", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		// Drop sessions once they can no longer be refreshed
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}},
+	{"follows", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "follower_id", Value: 1}, {Key: "followee_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Follower and following listings page by (created_at, _id)
+		{Keys: bson.D{{Key: "followee_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "follower_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+	}},
+	{"comments", []mongo.IndexModel{
+		// Listings page by (created_at, _id) within a post and parent
+		{Keys: bson.D{
+			{Key: "post_id", Value: 1},
+			{Key: "parent_id", Value: 1},
+			{Key: "created_at", Value: -1},
+			{Key: "_id", Value: -1},
+		}},
+		// Deleting a comment removes every reply below it
+		{Keys: bson.D{{Key: "ancestors", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	}},
+	{"reactions", []mongo.IndexModel{
+		// One reaction of each kind per user and post
+		{
+			Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "kind", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	}},
+}
+
+// createCollections creates the collections, skipping existing ones
+func createCollections(ctx context.Context, db *mongo.Database) error {
+	for _, name := range mongoCollections {
+		if err := db.CreateCollection(ctx, name); err != nil && !isNamespaceExists(err) {
+			return fmt.Errorf("creating collection %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// createIndexes creates mongoIndexes. Creating an index that already
+// exists with the same definition is a no-op.
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	// Signups used to race, so the unique indexes may not build
+	for _, field := range []string{"email", "username"} {
+		if err := checkUniqueIgnoringCase(ctx, db.Collection("users"), field); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range mongoIndexes {
+		if _, err := db.Collection(c.collection).Indexes().CreateMany(ctx, c.indexes); err != nil {
+			return fmt.Errorf("creating %s indexes: %w", c.collection, err)
+		}
+	}
+
+	// Only drop the non-unique user indexes once the unique ones replacing
+	// them exist, so a failed build leaves lookups indexed
+	for _, name := range []string{"email_1", "username_1"} {
+		if _, err := db.Collection("users").Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+			return fmt.Errorf("dropping index %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// maxReportedDuplicates caps how many conflicting values an error lists
+const maxReportedDuplicates = 20
+
+// checkUniqueIgnoringCase returns an error listing the values of field
+// that occur more than once in collection when compared case-insensitively
+func checkUniqueIgnoringCase(ctx context.Context, collection *mongo.Collection, field string) error {
+	cursor, err := collection.Aggregate(ctx, []bson.M{
+		{"$group": bson.M{
+			"_id":    bson.M{"$toLower": "$" + field},
+			"values": bson.M{"$push": "$" + field},
+			"count":  bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+		{"$limit": maxReportedDuplicates},
+	})
+	if err != nil {
+		return fmt.Errorf("checking %s %s values: %w", collection.Name(), field, err)
+	}
+
+	var duplicates []struct {
+		Values []string `bson:"values"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return fmt.Errorf("checking %s %s values: %w", collection.Name(), field, err)
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	groups := make([]string, 0, len(duplicates))
+	for _, d := range duplicates {
+		groups = append(groups, strings.Join(d.Values, ", "))
+	}
+	return fmt.Errorf("%s %s values must be unique ignoring case; rename or merge these and run the migration again: %s",
+		collection.Name(), field, strings.Join(groups, "; "))
+}
+
+// dropIndexes drops mongoIndexes, skipping missing ones
+func dropIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, c := range mongoIndexes {
+		for _, index := range c.indexes {
+			name := indexName(index)
+			if _, err := db.Collection(c.collection).Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+				return fmt.Errorf("dropping index %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rateLimitIndex drops rate limit buckets once they would have refilled
+var rateLimitIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "expires_at", Value: 1}},
+	Options: options.Index().SetName("rate_limits_expires_at_ttl").SetExpireAfterSeconds(0),
+}
+
+// createRateLimitIndex creates rateLimitIndex, and with it the rate_limits
+// collection
+func createRateLimitIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection("rate_limits").Indexes().CreateOne(ctx, rateLimitIndex); err != nil {
+		return fmt.Errorf("creating rate_limits index: %w", err)
+	}
+	return nil
+}
+
+// dropRateLimitIndex drops rateLimitIndex if it exists
+func dropRateLimitIndex(ctx context.Context, db *mongo.Database) error {
+	name := indexName(rateLimitIndex)
+	if _, err := db.Collection("rate_limits").Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+		return fmt.Errorf("dropping index %s: %w", name, err)
+	}
+	return nil
+}
+
+// normalizePostTags lowercases and trims the tags of posts written before
+// tags were normalized on write, strips a leading '#' and drops empty and
+// duplicate tags, so tag listings and trending tags find them
+func normalizePostTags(ctx context.Context, db *mongo.Database) error {
+	posts := db.Collection("posts")
+	cursor, err := posts.Find(ctx, bson.M{"tags.0": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"tags": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	models := make([]mongo.WriteModel, 0, 500)
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		_, err := posts.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		models = models[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var post struct {
+			ID   interface{} `bson:"_id"`
+			Tags []string    `bson:"tags"`
+		}
+		if err := cursor.Decode(&post); err != nil {
+			return err
+		}
+
+		tags := normalizeTags(post.Tags)
+		if slices.Equal(tags, post.Tags) {
+			continue
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": post.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"tags": tags}}))
+		if len(models) == cap(models) {
+			if err := flush(); err != nil {
+				return fmt.Errorf("normalizing post tags: %w", err)
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("normalizing post tags: %w", err)
+	}
+	return nil
+}
+
+// normalizeTags is the tag normalization of migration 4, kept here rather
+// than shared with the store so the migration can't change after release
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// indexName returns the name of index: the one set in its options, or the
+// server's default of its keys and directions joined by underscores
+func indexName(index mongo.IndexModel) string {
+	if index.Options != nil && index.Options.Name != nil {
+		return *index.Options.Name
+	}
+
+	var parts []string
+	for _, key := range index.Keys.(bson.D) {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// isIndexNotFound reports whether err is Mongo's IndexNotFound error or the
+// collection does not exist yet
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 27 || cmdErr.Code == 26 // IndexNotFound, NamespaceNotFound
+	}
+	return false
+}
+
+// isNamespaceExists reports whether err is Mongo's NamespaceExists error
+func isNamespaceExists(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == 48
+}
+
+// NewMongoMigrator returns a Migrator for the Mongo database db
+func NewMongoMigrator(db *mongo.Database) *Migrator {
+	host, _ := os.Hostname()
+	return &Migrator{driver: &mongoMigrationDriver{
+		db:    db,
+		owner: fmt.Sprintf("%s/%d/%s", host, os.Getpid(), primitive.NewObjectID().Hex()),
+	}}
+}
+
+// mongoMigrationDriver runs mongoMigrations. The lock is a lease document
+// renewed while migrations run and expiring if the holder dies.
+type mongoMigrationDriver struct {
+	db    *mongo.Database
+	owner string // Identifies this process as the lease holder
+}
+
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+func (d *mongoMigrationDriver) migrations() []migrationInfo {
+	infos := make([]migrationInfo, 0, len(mongoMigrations))
+	for _, m := range mongoMigrations {
+		infos = append(infos, migrationInfo{version: m.version, description: m.description, reversible: m.down != nil})
+	}
+	return infos
+}
+
+func (d *mongoMigrationDriver) migration(version int) mongoMigration {
+	for _, m := range mongoMigrations {
+		if m.version == version {
+			return m
+		}
+	}
+	panic(fmt.Sprintf("no Mongo migration %d", version))
+}
+
+func (d *mongoMigrationDriver) lock(ctx context.Context) (func(), error) {
+	locks := d.db.Collection(migrationLockCollection)
+	lease := bson.M{"_id": "migrations", "owner": d.owner}
+
+	waiting := false
+	for {
+		// Take the lease if it is free or expired. If another process holds
+		// it the filter misses and the upsert collides on _id.
+		now := time.Now()
+		_, err := locks.UpdateOne(ctx,
+			bson.M{"_id": "migrations", "expires_at": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": d.owner, "expires_at": now.Add(migrationLockTTL)}},
+			options.Update().SetUpsert(true))
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		if !waiting {
+			slog.Info("Waiting for another process to finish migrating")
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	// Renew the lease until unlocked
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(migrationLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				_, err := locks.UpdateOne(context.Background(), lease,
+					bson.M{"$set": bson.M{"expires_at": time.Now().Add(migrationLockTTL)}})
+				if err != nil {
+					slog.Warn("Renewing migration lock", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if _, err := locks.DeleteOne(ctx, lease); err != nil {
+			slog.Warn("Releasing migration lock", "error", err)
+		}
+	}, nil
+}
+
+func (d *mongoMigrationDriver) applied(ctx context.Context) (map[int]time.Time, error) {
+	cursor, err := d.db.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []migrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time, len(records))
+	for _, r := range records {
+		applied[r.Version] = r.AppliedAt
+	}
+	return applied, nil
+}
+
+func (d *mongoMigrationDriver) up(ctx context.Context, version int) error {
+	m := d.migration(version)
+	if err := m.up(ctx, d.db); err != nil {
+		return err
+	}
+
+	_, err := d.db.Collection(migrationsCollection).InsertOne(ctx, migrationRecord{
+		Version:     m.version,
+		Description: m.description,
+		AppliedAt:   time.Now(),
+	})
+	return err
+}
+
+func (d *mongoMigrationDriver) down(ctx context.Context, version int) error {
+	m := d.migration(version)
+	if err := m.down(ctx, d.db); err != nil {
+		return err
+	}
+
+	_, err := d.db.Collection(migrationsCollection).DeleteOne(ctx, bson.M{"_id": m.version})
+	return err
+}
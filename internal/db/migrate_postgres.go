@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations are pairs of files named NNNN_description.up.sql and
+// NNNN_description.down.sql. The down file is optional.
+//
+//go:embed migrations/postgres/*.sql
+var postgresMigrationFiles embed.FS
+
+// postgresMigrationLockKey identifies the advisory lock held while migrating
+const postgresMigrationLockKey = 0x676f70686572736f // "gopherso"
+
+// postgresMigration is a versioned SQL migration
+type postgresMigration struct {
+	version     int
+	description string
+	up, down    string // SQL scripts; down is empty if irreversible
+}
+
+// NewPostgresMigrator returns a Migrator for the PostgreSQL database db
+// running the embedded migrations
+func NewPostgresMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadPostgresMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{driver: &postgresMigrationDriver{db: db, list: migrations}}, nil
+}
+
+// loadPostgresMigrations reads the embedded migrations in version order
+func loadPostgresMigrations() ([]postgresMigration, error) {
+	files, err := fs.Glob(postgresMigrationFiles, "migrations/postgres/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*postgresMigration)
+	for _, file := range files {
+		name := path.Base(file)
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		versionStr, description, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || err != nil || version < 1 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_description.up.sql or .down.sql", name)
+		}
+
+		script, err := postgresMigrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &postgresMigration{version: version, description: description}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.up = string(script)
+		} else {
+			m.down = string(script)
+		}
+	}
+
+	migrations := make([]postgresMigration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %d %s has no up script", m.version, m.description)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+// postgresMigrationDriver runs SQL migrations, each in a transaction with
+// its schema_migrations record. The lock is a session-level advisory lock.
+type postgresMigrationDriver struct {
+	db   *sql.DB
+	list []postgresMigration
+}
+
+func (d *postgresMigrationDriver) migrations() []migrationInfo {
+	infos := make([]migrationInfo, 0, len(d.list))
+	for _, m := range d.list {
+		infos = append(infos, migrationInfo{version: m.version, description: m.description, reversible: m.down != ""})
+	}
+	return infos
+}
+
+func (d *postgresMigrationDriver) migration(version int) postgresMigration {
+	for _, m := range d.list {
+		if m.version == version {
+			return m
+		}
+	}
+	panic(fmt.Sprintf("no PostgreSQL migration %d", version))
+}
+
+func (d *postgresMigrationDriver) lock(ctx context.Context) (func(), error) {
+	// Advisory locks belong to a connection, so pin one until unlocked
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, int64(postgresMigrationLockKey)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	unlock := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, int64(postgresMigrationLockKey)); err != nil {
+			slog.Warn("Releasing migration lock", "error", err)
+		}
+		conn.Close()
+	}
+
+	_, err = d.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+
+	return unlock, nil
+}
+
+func (d *postgresMigrationDriver) applied(ctx context.Context) (map[int]time.Time, error) {
+	// Status may run before anything created the table
+	var exists bool
+	if err := d.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]time.Time)
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func (d *postgresMigrationDriver) up(ctx context.Context, version int) error {
+	m := d.migration(version)
+	return d.exec(ctx, m.up, `INSERT INTO schema_migrations (version) VALUES ($1)`, version)
+}
+
+func (d *postgresMigrationDriver) down(ctx context.Context, version int) error {
+	m := d.migration(version)
+	return d.exec(ctx, m.down, `DELETE FROM schema_migrations WHERE version = $1`, version)
+}
+
+// exec runs script and then record with version in one transaction
+func (d *postgresMigrationDriver) exec(ctx context.Context, script, record string, version int) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeDriver records the migrations it runs against an in-memory set of
+// applied versions
+type fakeDriver struct {
+	infos  []migrationInfo
+	state  map[int]time.Time
+	ran    []int         // positive for up, negative for down
+	fail   map[int]error // up steps that fail instead of running
+	locked bool
+}
+
+func newFakeDriver(applied ...int) *fakeDriver {
+	d := &fakeDriver{
+		infos: []migrationInfo{
+			{version: 1, description: "one", reversible: false},
+			{version: 2, description: "two", reversible: true},
+			{version: 3, description: "three", reversible: true},
+		},
+		state: map[int]time.Time{},
+	}
+	for _, v := range applied {
+		d.state[v] = time.Now()
+	}
+	return d
+}
+
+func (d *fakeDriver) migrations() []migrationInfo { return d.infos }
+
+func (d *fakeDriver) lock(ctx context.Context) (func(), error) {
+	d.locked = true
+	return func() { d.locked = false }, nil
+}
+
+func (d *fakeDriver) applied(ctx context.Context) (map[int]time.Time, error) {
+	applied := make(map[int]time.Time, len(d.state))
+	for v, at := range d.state {
+		applied[v] = at
+	}
+	return applied, nil
+}
+
+func (d *fakeDriver) up(ctx context.Context, version int) error {
+	if !d.locked {
+		return errors.New("up without lock")
+	}
+	if err := d.fail[version]; err != nil {
+		return err
+	}
+	d.state[version] = time.Now()
+	d.ran = append(d.ran, version)
+	return nil
+}
+
+func (d *fakeDriver) down(ctx context.Context, version int) error {
+	if !d.locked {
+		return errors.New("down without lock")
+	}
+	delete(d.state, version)
+	d.ran = append(d.ran, -version)
+	return nil
+}
+
+func TestMigrator(t *testing.T) {
+	tests := []struct {
+		name    string
+		applied []int
+		run     func(*Migrator, context.Context) error
+		wantRan []int
+		wantErr error
+	}{
+		{name: "up from empty", run: (*Migrator).Up, wantRan: []int{1, 2, 3}},
+		{name: "up fills gaps", applied: []int{1, 3}, run: (*Migrator).Up, wantRan: []int{2}},
+		{name: "up when current", applied: []int{1, 2, 3}, run: (*Migrator).Up},
+		{name: "down", applied: []int{1, 2, 3}, run: (*Migrator).Down, wantRan: []int{-3}},
+		{name: "down when empty", run: (*Migrator).Down},
+		{name: "down irreversible", applied: []int{1}, run: (*Migrator).Down, wantErr: ErrIrreversibleMigration},
+		{name: "down unknown", applied: []int{1, 2, 3, 4}, run: (*Migrator).Down, wantErr: ErrUnknownMigration},
+		{name: "to higher", applied: []int{1}, run: to(2), wantRan: []int{2}},
+		{name: "to lower", applied: []int{1, 2, 3}, run: to(1), wantRan: []int{-3, -2}},
+		{name: "to lower and fill gap", applied: []int{1, 3}, run: to(2), wantRan: []int{-3, 2}},
+		{name: "to zero through irreversible", applied: []int{1, 2}, run: to(0), wantErr: ErrIrreversibleMigration},
+		{name: "to unknown", run: to(9), wantErr: ErrUnknownMigration},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			driver := newFakeDriver(tc.applied...)
+			err := tc.run(&Migrator{driver: driver}, context.Background())
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(driver.ran, tc.wantRan) {
+				t.Errorf("ran %v, want %v", driver.ran, tc.wantRan)
+			}
+			if driver.locked {
+				t.Error("lock still held")
+			}
+		})
+	}
+}
+
+func TestMigratorRetriesFailedMigration(t *testing.T) {
+	errBuild := errors.New("index build failed")
+	driver := newFakeDriver()
+	driver.fail = map[int]error{2: errBuild}
+	m := &Migrator{driver: driver}
+
+	if err := m.Up(context.Background()); !errors.Is(err, errBuild) {
+		t.Fatalf("err = %v, want %v", err, errBuild)
+	}
+	if _, ok := driver.state[2]; ok {
+		t.Fatal("failed migration recorded as applied")
+	}
+	if driver.locked {
+		t.Fatal("lock still held after failure")
+	}
+
+	// Once the cause is fixed, running again picks up where it stopped
+	delete(driver.fail, 2)
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(driver.ran, want) {
+		t.Errorf("ran %v, want %v", driver.ran, want)
+	}
+}
+
+func to(version int) func(*Migrator, context.Context) error {
+	return func(m *Migrator, ctx context.Context) error {
+		return m.To(ctx, version)
+	}
+}
+
+func TestMigratorStatus(t *testing.T) {
+	driver := newFakeDriver(1, 4)
+
+	statuses, err := (&Migrator{driver: driver}).Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, s := range statuses {
+		state := "pending"
+		if s.AppliedAt != nil {
+			state = "applied"
+		}
+		if !s.Known {
+			state += " unknown"
+		}
+		got = append(got, s.Description+" "+state)
+	}
+	want := []string{"one applied", "two pending", "three pending", " applied unknown"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %q, want %q", got, want)
+	}
+}
+
+func TestLoadPostgresMigrations(t *testing.T) {
+	migrations, err := loadPostgresMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %d %s: versions must be consecutive from 1", m.version, m.description)
+		}
+		if m.up == "" {
+			t.Errorf("migration %d %s has no up script", m.version, m.description)
+		}
+	}
+}
+
+func TestMongoMigrationsAreOrdered(t *testing.T) {
+	for i, m := range mongoMigrations {
+		if m.version != i+1 {
+			t.Errorf("migration %d %s: versions must be consecutive from 1", m.version, m.description)
+		}
+		if m.up == nil {
+			t.Errorf("migration %d %s has no up step", m.version, m.description)
+		}
+	}
+}
+
+func TestIndexName(t *testing.T) {
+	// Dropping indexes relies on matching the server's default names
+	want := map[string]bool{
+		"users_email_unique":             true,
+		"created_at_-1__id_-1":           true,
+		"follower_id_1_followee_id_1":    true,
+		"post_id_1_user_id_1_kind_1":     true,
+		"posts_text":                     true,
+		"tags_1_created_at_-1__id_-1":    true,
+		"user_id_1_created_at_-1__id_-1": true,
+	}
+	for _, c := range mongoIndexes {
+		for _, index := range c.indexes {
+			delete(want, indexName(index))
+		}
+	}
+	for name := range want {
+		t.Errorf("no index named %s", name)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{"Go", " go ", "#Concurrency", "", "  ", "concurrency", "web"})
+	want := []string{"go", "concurrency", "web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeTags = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS reactions;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS follows;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS users;
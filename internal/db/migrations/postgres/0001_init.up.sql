@@ -0,0 +1,96 @@
+-- IDs are 24 hex character strings generated by the application
+-- (store.NewID) so they look the same on every backend.
+
+CREATE TABLE users (
+    id                      CHAR(24) PRIMARY KEY,
+    username                TEXT NOT NULL,
+    email                   TEXT NOT NULL,
+    password                TEXT NOT NULL,
+    bio                     TEXT NOT NULL DEFAULT '',
+    pending_email           TEXT NOT NULL DEFAULT '',
+    email_change_token_hash TEXT NOT NULL DEFAULT '',
+    email_change_expires_at TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL,
+    updated_at              TIMESTAMPTZ NOT NULL
+);
+
+-- Case-insensitive, like the Mongo indexes with strength 2 collation
+CREATE UNIQUE INDEX users_email_unique ON users (lower(email));
+CREATE UNIQUE INDEX users_username_unique ON users (lower(username));
+CREATE INDEX users_email_change_token_hash ON users (email_change_token_hash)
+    WHERE email_change_token_hash <> '';
+
+CREATE TABLE posts (
+    id             CHAR(24) PRIMARY KEY,
+    user_id        CHAR(24) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    title          TEXT NOT NULL,
+    content        TEXT NOT NULL,
+    tags           TEXT[] NOT NULL DEFAULT '{}',
+    comments_count BIGINT NOT NULL DEFAULT 0,
+    reactions      JSONB NOT NULL DEFAULT '{}', -- Count per reaction kind
+    search         TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', content), 'B')
+    ) STORED,
+    created_at     TIMESTAMPTZ NOT NULL,
+    updated_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX posts_created_at_id ON posts (created_at DESC, id DESC);
+CREATE INDEX posts_user_id_created_at_id ON posts (user_id, created_at DESC, id DESC);
+CREATE INDEX posts_tags ON posts USING GIN (tags);
+CREATE INDEX posts_search ON posts USING GIN (search);
+
+CREATE TABLE sessions (
+    id                    CHAR(24) PRIMARY KEY,
+    user_id               CHAR(24) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash            TEXT NOT NULL,
+    previous_token_hashes TEXT[] NOT NULL DEFAULT '{}',
+    user_agent            TEXT NOT NULL DEFAULT '',
+    ip                    TEXT NOT NULL DEFAULT '',
+    created_at            TIMESTAMPTZ NOT NULL,
+    last_used_at          TIMESTAMPTZ NOT NULL,
+    expires_at            TIMESTAMPTZ NOT NULL,
+    revoked_at            TIMESTAMPTZ
+);
+
+CREATE INDEX sessions_user_id ON sessions (user_id);
+
+CREATE TABLE follows (
+    id          CHAR(24) PRIMARY KEY,
+    follower_id CHAR(24) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id CHAR(24) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL,
+    UNIQUE (follower_id, followee_id)
+);
+
+CREATE INDEX follows_follower_id_created_at_id ON follows (follower_id, created_at DESC, id DESC);
+CREATE INDEX follows_followee_id_created_at_id ON follows (followee_id, created_at DESC, id DESC);
+
+CREATE TABLE comments (
+    id            CHAR(24) PRIMARY KEY,
+    post_id       CHAR(24) NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    user_id       CHAR(24) REFERENCES users (id) ON DELETE SET NULL, -- NULL once the author is deleted
+    parent_id     CHAR(24) REFERENCES comments (id) ON DELETE CASCADE,
+    ancestors     CHAR(24)[] NOT NULL DEFAULT '{}',
+    depth         INTEGER NOT NULL DEFAULT 0,
+    content       TEXT NOT NULL,
+    replies_count BIGINT NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX comments_post_id_parent_id_created_at_id ON comments (post_id, parent_id, created_at DESC, id DESC);
+CREATE INDEX comments_ancestors ON comments USING GIN (ancestors);
+CREATE INDEX comments_user_id ON comments (user_id);
+
+CREATE TABLE reactions (
+    id         CHAR(24) PRIMARY KEY,
+    post_id    CHAR(24) NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    user_id    CHAR(24) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (post_id, user_id, kind)
+);
+
+CREATE INDEX reactions_user_id ON reactions (user_id);
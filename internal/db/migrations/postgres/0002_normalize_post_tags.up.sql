@@ -0,0 +1,20 @@
+-- Tags are lowercased, trimmed and stripped of a leading '#' on write, and
+-- listings match them exactly. Bring posts written before that in line,
+-- keeping the first occurrence of each tag.
+
+UPDATE posts
+SET tags = normalized.tags
+FROM (
+    SELECT id, COALESCE(array_agg(tag ORDER BY pos) FILTER (WHERE tag <> ''), '{}') AS tags
+    FROM (
+        SELECT src.id, t.tag, min(t.pos) AS pos
+        FROM posts AS src
+        CROSS JOIN LATERAL (
+            SELECT lower(regexp_replace(regexp_replace(raw, '^\s+|\s+$', '', 'g'), '^#', '')), pos
+            FROM unnest(src.tags) WITH ORDINALITY AS u(raw, pos)
+        ) AS t(tag, pos)
+        GROUP BY src.id, t.tag
+    ) AS deduped
+    GROUP BY id
+) AS normalized
+WHERE posts.id = normalized.id AND posts.tags <> normalized.tags;
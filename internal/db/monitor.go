@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans of database commands
+const tracerName = "github.com/Nutan-Kum12/Gopherso/internal/db"
+
+// MongoMonitor observes a Mongo client's connection pool and commands. It
+// keeps pool counts for health checks, since the driver has no API to read
+// them, exports them along with command latency as Prometheus metrics and
+// records a span per command.
+type MongoMonitor struct {
+	open  atomic.Int64
+	inUse atomic.Int64
+
+	checkoutFailures prometheus.Counter
+	commandDuration  *prometheus.HistogramVec
+
+	tracer trace.Tracer
+	spans  sync.Map // commandKey to the span of a running command
+}
+
+// commandKey identifies a running command; request IDs are only unique
+// per connection
+type commandKey struct {
+	connection string
+	requestID  int64
+}
+
+// NewMongoMonitor returns a monitor registering its metrics with reg and
+// creating spans with tp
+func NewMongoMonitor(reg prometheus.Registerer, tp trace.TracerProvider) *MongoMonitor {
+	m := &MongoMonitor{
+		tracer: tp.Tracer(tracerName),
+		checkoutFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gopherso_mongo_pool_checkout_failures_total",
+			Help: "Connection checkouts from the MongoDB pool that failed.",
+		}),
+		commandDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gopherso_mongo_command_duration_seconds",
+			Help:    "Latency of MongoDB commands by command name and status.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14), // 0.5ms to ~4s
+		}, []string{"command", "status"}),
+	}
+
+	reg.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "gopherso_mongo_pool_connections",
+			Help:        "Connections in the MongoDB pool by state.",
+			ConstLabels: prometheus.Labels{"state": "open"},
+		}, func() float64 { return float64(m.Open()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "gopherso_mongo_pool_connections",
+			Help:        "Connections in the MongoDB pool by state.",
+			ConstLabels: prometheus.Labels{"state": "in_use"},
+		}, func() float64 { return float64(m.InUse()) }),
+		m.checkoutFailures,
+		m.commandDuration,
+	)
+	return m
+}
+
+// Open returns the number of connections in the pool, idle or in use
+func (m *MongoMonitor) Open() int64 { return m.open.Load() }
+
+// InUse returns the number of connections checked out of the pool
+func (m *MongoMonitor) InUse() int64 { return m.inUse.Load() }
+
+func (m *MongoMonitor) poolEvent(e *event.PoolEvent) {
+	switch e.Type {
+	case event.ConnectionCreated:
+		m.open.Add(1)
+	case event.ConnectionClosed:
+		m.open.Add(-1)
+	case event.GetSucceeded:
+		m.inUse.Add(1)
+	case event.ConnectionReturned:
+		m.inUse.Add(-1)
+	case event.GetFailed:
+		m.checkoutFailures.Inc()
+	}
+}
+
+func (m *MongoMonitor) commandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started:   m.commandStarted,
+		Succeeded: m.commandSucceeded,
+		Failed:    m.commandFailed,
+	}
+}
+
+// commandStarted starts a span for the command, parented to the span in
+// the context of the operation that issued it
+func (m *MongoMonitor) commandStarted(ctx context.Context, e *event.CommandStartedEvent) {
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemNameMongoDB,
+		semconv.DBNamespace(e.DatabaseName),
+		semconv.DBOperationName(e.CommandName),
+	}
+	name := e.CommandName
+	// The command document starts with the command name mapped to the
+	// collection it runs against
+	if first, err := e.Command.IndexErr(0); err == nil {
+		if collection, ok := first.Value().StringValueOK(); ok {
+			attrs = append(attrs, semconv.DBCollectionName(collection))
+			name += " " + collection
+		}
+	}
+
+	_, span := m.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	m.spans.Store(commandKey{e.ConnectionID, e.RequestID}, span)
+}
+
+func (m *MongoMonitor) commandSucceeded(_ context.Context, e *event.CommandSucceededEvent) {
+	m.commandDuration.WithLabelValues(e.CommandName, "ok").Observe(e.Duration.Seconds())
+	if span, ok := m.spans.LoadAndDelete(commandKey{e.ConnectionID, e.RequestID}); ok {
+		span.(trace.Span).End()
+	}
+}
+
+func (m *MongoMonitor) commandFailed(_ context.Context, e *event.CommandFailedEvent) {
+	m.commandDuration.WithLabelValues(e.CommandName, "error").Observe(e.Duration.Seconds())
+	if v, ok := m.spans.LoadAndDelete(commandKey{e.ConnectionID, e.RequestID}); ok {
+		span := v.(trace.Span)
+		span.SetStatus(codes.Error, e.Failure)
+		span.End()
+	}
+}
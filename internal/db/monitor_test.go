@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestMongoMonitorTracksPool(t *testing.T) {
+	m := NewMongoMonitor(prometheus.NewRegistry(), noop.NewTracerProvider())
+	for _, typ := range []string{
+		event.ConnectionCreated, event.ConnectionCreated, event.ConnectionCreated,
+		event.GetSucceeded, event.GetSucceeded, event.ConnectionReturned,
+		event.ConnectionClosed, event.GetFailed,
+	} {
+		m.poolEvent(&event.PoolEvent{Type: typ})
+	}
+
+	if m.Open() != 2 || m.InUse() != 1 {
+		t.Errorf("open, in use = %d, %d, want 2, 1", m.Open(), m.InUse())
+	}
+	if got := testutil.ToFloat64(m.checkoutFailures); got != 1 {
+		t.Errorf("checkout failures = %v, want 1", got)
+	}
+}
+
+func TestMongoMonitorObservesCommands(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	m := NewMongoMonitor(prometheus.NewRegistry(), tp)
+	monitor := m.commandMonitor()
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	for i, name := range []string{"find", "insert"} {
+		command, err := bson.Marshal(bson.D{{Key: name, Value: "posts"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		monitor.Started(ctx, &event.CommandStartedEvent{
+			Command:      command,
+			CommandName:  name,
+			DatabaseName: "gopherso",
+			RequestID:    int64(i),
+			ConnectionID: "conn",
+		})
+	}
+	finished := func(id int64, name string) event.CommandFinishedEvent {
+		return event.CommandFinishedEvent{CommandName: name, RequestID: id, ConnectionID: "conn", Duration: time.Millisecond}
+	}
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished(0, "find")})
+	monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: finished(1, "insert"), Failure: "boom"})
+	parent.End()
+
+	if got := testutil.CollectAndCount(m.commandDuration); got != 2 {
+		t.Errorf("series = %d, want find/ok and insert/error", got)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("ended %d spans, want 3", len(spans))
+	}
+	for i, want := range []struct {
+		name   string
+		status codes.Code
+	}{{"find posts", codes.Unset}, {"insert posts", codes.Error}} {
+		span := spans[i]
+		if span.Name() != want.name || span.Status().Code != want.status {
+			t.Errorf("span %d = %q %v, want %q %v", i, span.Name(), span.Status().Code, want.name, want.status)
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %q is not a child of the operation's span", span.Name())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRedactsSensitiveAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("login",
+		"email", "alice@example.com",
+		"password", "hunter2",
+		slog.Group("tokens", "refresh_token", "abc", "kind", "bearer"),
+		"Authorization", "Bearer xyz",
+	)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	tokens, _ := entry["tokens"].(map[string]any)
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"email", entry["email"], "alice@example.com"},
+		{"password", entry["password"], redacted},
+		{"tokens.refresh_token", tokens["refresh_token"], redacted},
+		{"tokens.kind", tokens["kind"], "bearer"},
+		{"Authorization", entry["Authorization"], redacted},
+	}
+	for _, tc := range tests {
+		if tc.got != tc.want {
+			t.Errorf("%s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	tests := []struct {
+		level, format string
+		ok            bool
+	}{
+		{"debug", "json", true},
+		{"WARN", "text", true},
+		{"verbose", "json", false},
+		{"info", "xml", false},
+	}
+	for _, tc := range tests {
+		if _, err := New(&bytes.Buffer{}, tc.level, tc.format); (err == nil) != tc.ok {
+			t.Errorf("New(%q, %q) err = %v, want ok = %v", tc.level, tc.format, err, tc.ok)
+		}
+	}
+}
+
+func TestFiltersByLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	if bytes.Contains(buf.Bytes(), []byte("hidden")) || !bytes.Contains(buf.Bytes(), []byte("shown")) {
+		t.Errorf("output = %q, want only the warning", buf.String())
+	}
+}
@@ -0,0 +1,20 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogMailer writes emails to the default logger instead of sending them.
+// Intended for local development. Bodies carry tokens, so only the envelope
+// is logged; use FileMailer to read them.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	slog.Info("Mail", "to", msg.To, "from", msg.From, "subject", msg.Subject)
+	return nil
+}
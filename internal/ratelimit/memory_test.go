@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryTake(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	policy := Policy{Name: "test", Limit: 3, Period: 3 * time.Second}
+	take := func() Result {
+		t.Helper()
+		res, err := m.Take(context.Background(), "client", policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	// A full bucket allows a burst of Limit requests
+	for want := 2; want >= 0; want-- {
+		if res := take(); !res.Allowed || res.Remaining != want {
+			t.Fatalf("burst: got %+v, want allowed with %d remaining", res, want)
+		}
+	}
+
+	res := take()
+	if res.Allowed || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Fatalf("empty bucket: got %+v, want denied, retry after 1s, reset in 3s", res)
+	}
+
+	// One token comes back per second
+	now = now.Add(time.Second)
+	if res := take(); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after 1s: got %+v, want allowed with 0 remaining", res)
+	}
+
+	// Other keys have their own bucket
+	if res, _ := m.Take(context.Background(), "other", policy); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("other key: got %+v, want allowed with 2 remaining", res)
+	}
+
+	// Refilled buckets are swept
+	now = now.Add(time.Hour)
+	take()
+	if len(m.buckets) != 1 {
+		t.Errorf("%d buckets after sweep, want 1", len(m.buckets))
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Mongo keeps buckets in the rate_limits collection so replicas share
+// them. Buckets are refilled by the server using its own clock, and
+// expire through a TTL index on expires_at once they would be full.
+type Mongo struct {
+	buckets *mongo.Collection
+}
+
+// NewMongo returns a Store backed by db
+func NewMongo(db *mongo.Database) *Mongo {
+	return &Mongo{buckets: db.Collection("rate_limits")}
+}
+
+// Take implements Store with a single atomic upsert
+func (m *Mongo) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	capacity := float64(policy.Limit)
+	perMilli := policy.rate() / 1000
+
+	// Refill for the milliseconds since the last update, then take a
+	// token if a whole one is left. A missing bucket starts full.
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{capacity, bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$tokens", capacity}},
+				bson.M{"$multiply": bson.A{
+					bson.M{"$subtract": bson.A{"$$NOW", bson.M{"$ifNull": bson.A{"$updated_at", "$$NOW"}}}},
+					perMilli,
+				}},
+			}}}},
+			"updated_at": "$$NOW",
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed": bson.M{"$gte": bson.A{"$tokens", 1}},
+			"tokens": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$tokens", 1}},
+				bson.M{"$subtract": bson.A{"$tokens", 1}},
+				"$tokens",
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"expires_at": bson.M{"$add": bson.A{
+				"$$NOW",
+				bson.M{"$ceil": bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{capacity, "$tokens"}}, perMilli}}},
+			}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	err := m.buckets.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&doc)
+	if mongo.IsDuplicateKeyError(err) {
+		// Two first requests raced to insert the bucket; the loser updates it
+		err = m.buckets.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&doc)
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("taking rate limit token: %w", err)
+	}
+
+	return newResult(policy, doc.Tokens, doc.Allowed), nil
+}
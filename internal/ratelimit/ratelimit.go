@@ -0,0 +1,58 @@
+// Package ratelimit implements token bucket rate limiting with pluggable
+// bucket stores.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy allows Limit requests per Period. Buckets start full and refill
+// continuously, so a client may burst up to Limit requests at once.
+type Policy struct {
+	Name   string // Identifies the policy in bucket keys and metrics
+	Limit  int
+	Period time.Duration
+}
+
+// rate returns how many tokens the bucket regains per second
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int           // Whole tokens left after this request
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next token; zero when allowed
+}
+
+// Store holds token buckets. Take refills the bucket of key under policy,
+// then takes a token from it if one is left.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// newResult describes a bucket of policy holding tokens after a request
+// that was allowed or not
+func newResult(policy Policy, tokens float64, allowed bool) Result {
+	rate := policy.rate()
+	res := Result{
+		Allowed:   allowed,
+		Limit:     policy.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(policy.Limit) - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return res
+}
+
+// seconds converts fractional seconds to a Duration
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
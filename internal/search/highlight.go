@@ -0,0 +1,138 @@
+package search
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Terms returns the positive terms and phrases of a text query, i.e.
+// everything except "-excluded" words and phrases, lowercased
+func Terms(q string) []string {
+	terms, _ := Parse(q)
+	return terms
+}
+
+// Parse splits a text query into its positive terms and phrases and its
+// "-excluded" ones, lowercased
+func Parse(q string) (terms, excluded []string) {
+	// Quoted phrases first, then bare words from what is left
+	rest := phraseRe.ReplaceAllStringFunc(q, func(m string) string {
+		negated := strings.HasPrefix(m, "-")
+		phrase := strings.ToLower(strings.TrimSpace(strings.Trim(strings.TrimPrefix(m, "-"), `"`)))
+		switch {
+		case phrase == "":
+		case negated:
+			excluded = append(excluded, phrase)
+		default:
+			terms = append(terms, phrase)
+		}
+		return " "
+	})
+
+	for _, word := range strings.Fields(rest) {
+		negated := strings.HasPrefix(word, "-")
+		word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}))
+		switch {
+		case word == "":
+		case negated:
+			excluded = append(excluded, word)
+		default:
+			terms = append(terms, word)
+		}
+	}
+
+	return terms, excluded
+}
+
+var phraseRe = regexp.MustCompile(`-?"[^"]*"`)
+
+// Highlight HTML-escapes text and wraps every occurrence of terms in
+// <mark></mark>. Matching is case-insensitive and on word prefixes, which
+// roughly follows the stemming MongoDB applies to text queries.
+func Highlight(text string, terms []string) string {
+	re := termsRegexp(terms)
+	if re == nil {
+		return html.EscapeString(text)
+	}
+	return mark(text, re)
+}
+
+// Snippet returns a highlighted excerpt of at most about maxLen runes of
+// text, centred on the first match of terms. Without a match the start of
+// text is used.
+func Snippet(text string, terms []string, maxLen int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return Highlight(text, terms)
+	}
+
+	start := 0
+	if re := termsRegexp(terms); re != nil {
+		if loc := re.FindStringIndex(text); loc != nil {
+			matchStart := len([]rune(text[:loc[0]]))
+			start = max(matchStart-maxLen/3, 0)
+		}
+	}
+	end := min(start+maxLen, len(runes))
+	start = max(end-maxLen, 0)
+
+	// Avoid cutting words in half
+	for start > 0 && !unicode.IsSpace(runes[start-1]) && start < end {
+		start++
+	}
+	for end < len(runes) && !unicode.IsSpace(runes[end]) && end > start {
+		end--
+	}
+
+	excerpt := strings.TrimSpace(string(runes[start:end]))
+	snippet := Highlight(excerpt, terms)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// Count returns how many times terms occur in text, matched the same way
+// Highlight marks them
+func Count(text string, terms []string) int {
+	re := termsRegexp(terms)
+	if re == nil {
+		return 0
+	}
+	return len(re.FindAllStringIndex(text, -1))
+}
+
+// termsRegexp matches any of terms at the start of a word
+func termsRegexp(terms []string) *regexp.Regexp {
+	if len(terms) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)[\p{L}\p{N}]*`)
+}
+
+// mark escapes text and wraps the matches of re, escaping each piece
+// separately so the tags themselves are not escaped
+func mark(text string, re *regexp.Regexp) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[loc[0]:loc[1]]))
+		b.WriteString("</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrParentMismatch is returned when a reply's parent belongs to another post
+var ErrParentMismatch = errors.New("parent comment belongs to another post")
+
+// Comment is a comment on a post or a reply to another comment. Ancestors
+// lists the IDs from the top-level comment down to the direct parent, so
+// Depth is len(Ancestors) and whole threads can be deleted at once.
+type Comment struct {
+	ID           ID        `json:"id" bson:"_id,omitempty"`
+	PostID       ID        `json:"post_id" bson:"post_id"`
+	UserID       ID        `json:"user_id" bson:"user_id"`
+	ParentID     *ID       `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Ancestors    []ID      `json:"-" bson:"ancestors"`
+	Depth        int       `json:"depth" bson:"depth"`
+	Content      string    `json:"content" bson:"content"`
+	RepliesCount int64     `json:"replies_count" bson:"replies_count"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// cursor returns the pagination position of the comment
+func (c Comment) cursor() Cursor {
+	return Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+}
+
+// CommentWithUser represents a comment with its author. User is nil when
+// the author's account has been deleted.
+type CommentWithUser struct {
+	Comment `bson:",inline"`
+	User    *User `json:"user" bson:"user,omitempty"`
+}
+
+type CommentStore struct {
+	collection      *mongo.Collection
+	postsCollection *mongo.Collection
+}
+
+// Create adds a comment to a post, or a reply when ParentID is set, and
+// bumps the post's and parent's counters
+func (s *CommentStore) Create(ctx context.Context, comment *Comment) error {
+	comment.Ancestors = []ID{}
+	comment.Depth = 0
+
+	if comment.ParentID != nil {
+		parent, err := s.GetByID(ctx, *comment.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.PostID != comment.PostID {
+			return ErrParentMismatch
+		}
+		comment.Ancestors = append(append(comment.Ancestors, parent.Ancestors...), parent.ID)
+		comment.Depth = parent.Depth + 1
+	}
+
+	comment.ID = NewID()
+	comment.RepliesCount = 0
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = time.Now()
+
+	if _, err := s.collection.InsertOne(ctx, comment); err != nil {
+		return err
+	}
+
+	if err := s.incCommentsCount(ctx, comment.PostID, 1); err != nil {
+		return err
+	}
+
+	if comment.ParentID != nil {
+		_, err := s.collection.UpdateOne(ctx, bson.M{"_id": *comment.ParentID},
+			bson.M{"$inc": bson.M{"replies_count": 1}})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetByID retrieves a comment by its ID
+func (s *CommentStore) GetByID(ctx context.Context, commentID ID) (*Comment, error) {
+	var comment Comment
+	err := s.collection.FindOne(ctx, bson.M{"_id": commentID}).Decode(&comment)
+	if err != nil {
+		return nil, mapNotFound(err)
+	}
+	return &comment, nil
+}
+
+// GetByPostID retrieves a page of comments on a post with their authors,
+// newest first. With a nil parentID only top-level comments are returned,
+// otherwise the direct replies to that comment.
+func (s *CommentStore) GetByPostID(ctx context.Context, postID ID, parentID *ID, page Page) ([]CommentWithUser, *Cursor, error) {
+	filter := bson.M{"post_id": postID, "parent_id": nil}
+	if parentID != nil {
+		filter["parent_id"] = *parentID
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": page.filter(filter),
+		},
+		{
+			"$sort": pageSort,
+		},
+		{
+			"$limit": page.fetchLimit(),
+		},
+		{
+			"$lookup": bson.M{
+				"from":         "users",
+				"localField":   "user_id",
+				"foreignField": "_id",
+				"as":           "user",
+			},
+		},
+		{
+			// Keep comments by deleted users
+			"$unwind": bson.M{"path": "$user", "preserveNullAndEmptyArrays": true},
+		},
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []CommentWithUser
+	if err = cursor.All(ctx, &comments); err != nil {
+		return nil, nil, err
+	}
+
+	comments, next := trimPage(page, comments, CommentWithUser.cursor)
+	return comments, next, nil
+}
+
+// Update changes the content of a comment (only by its author)
+func (s *CommentStore) Update(ctx context.Context, commentID, userID ID, content string) error {
+	filter := bson.M{
+		"_id":     commentID,
+		"user_id": userID, // Ensure only the author can edit
+	}
+
+	update := bson.M{"$set": bson.M{"content": content, "updated_at": time.Now()}}
+
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return s.ownershipError(ctx, commentID)
+	}
+
+	return nil
+}
+
+// Delete deletes a comment and all replies below it. Only the comment's
+// author or the owner of the post may delete it.
+func (s *CommentStore) Delete(ctx context.Context, commentID, userID ID) error {
+	comment, err := s.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		var post Post
+		err := s.postsCollection.FindOne(ctx, bson.M{"_id": comment.PostID},
+			options.FindOne().SetProjection(bson.M{"user_id": 1})).Decode(&post)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		if post.UserID != userID {
+			return ErrForbidden
+		}
+	}
+
+	result, err := s.collection.DeleteMany(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"_id": commentID},
+			bson.M{"ancestors": commentID},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	if err := s.incCommentsCount(ctx, comment.PostID, -result.DeletedCount); err != nil {
+		return err
+	}
+
+	if comment.ParentID != nil {
+		_, err := s.collection.UpdateOne(ctx, bson.M{"_id": *comment.ParentID},
+			bson.M{"$inc": bson.M{"replies_count": -1}})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// incCommentsCount adjusts the denormalized comment counter of a post
+func (s *CommentStore) incCommentsCount(ctx context.Context, postID ID, delta int64) error {
+	_, err := s.postsCollection.UpdateOne(ctx, bson.M{"_id": postID},
+		bson.M{"$inc": bson.M{"comments_count": delta}})
+	return err
+}
+
+// ownershipError explains why an author-filtered write matched nothing:
+// ErrNotFound if the comment does not exist, ErrForbidden otherwise
+func (s *CommentStore) ownershipError(ctx context.Context, commentID ID) error {
+	count, err := s.collection.CountDocuments(ctx, bson.M{"_id": commentID}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNotFound is returned when the requested entity does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned when a write conflicts with existing data. For
+// unique index violations the concrete error is a *DuplicateError naming
+// the conflicting field.
+var ErrConflict = errors.New("conflict")
+
+// ErrUnavailable is returned when the database can't be reached or doesn't
+// answer in time. It wraps the driver error.
+var ErrUnavailable = errors.New("database unavailable")
+
+// DuplicateError reports which unique field a write conflicted on
+type DuplicateError struct {
+	Field string
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("duplicate value for %s", e.Field)
+}
+
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// dupKeyField extracts the first key of the "dup key" section of a
+// duplicate key error message, e.g.
+//
+//	E11000 duplicate key error collection: gopherso.users index: users_email_unique dup key: { email: "a@b.c" }
+var dupKeyField = regexp.MustCompile(`dup key: \{ ?"?([A-Za-z0-9_.]+)"?:`)
+
+// mapDuplicateKey converts Mongo duplicate key errors into *DuplicateError
+// and returns any other error unchanged
+func mapDuplicateKey(err error) error {
+	if err == nil || !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	field := "unknown"
+	if m := dupKeyField.FindStringSubmatch(err.Error()); m != nil {
+		field = m[1]
+	}
+	return &DuplicateError{Field: field}
+}
+
+// mapNotFound converts mongo.ErrNoDocuments into ErrNotFound and returns
+// any other error unchanged
+func mapNotFound(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// wrapMongoError types errors leaving the Mongo stores so callers don't
+// need to know the driver: timeouts and network failures wrap
+// ErrUnavailable, and missing documents or duplicate keys a method didn't
+// map itself become ErrNotFound and *DuplicateError
+func wrapMongoError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case mongo.IsTimeout(err), mongo.IsNetworkError(err), errors.Is(err, mongo.ErrClientDisconnected):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	default:
+		return mapDuplicateKey(mapNotFound(err))
+	}
+}
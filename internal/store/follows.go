@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Follow is a directed edge of the social graph: FollowerID follows FolloweeID
+type Follow struct {
+	ID         ID        `json:"id" bson:"_id,omitempty"`
+	FollowerID ID        `json:"follower_id" bson:"follower_id"`
+	FolloweeID ID        `json:"followee_id" bson:"followee_id"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+}
+
+// cursor returns the pagination position of the follow
+func (f Follow) cursor() Cursor {
+	return Cursor{CreatedAt: f.CreatedAt, ID: f.ID}
+}
+
+// FollowWithUser represents a follow edge with the user on the other end
+type FollowWithUser struct {
+	Follow `bson:",inline"`
+	User   User `json:"user" bson:"user,omitempty"`
+}
+
+type FollowStore struct {
+	collection *mongo.Collection
+	fanout     *TimelineFanout // nil unless fan-out-on-write is enabled
+}
+
+// Follow makes followerID follow followeeID. Following twice is a no-op.
+func (s *FollowStore) Follow(ctx context.Context, followerID, followeeID ID) error {
+	filter := bson.M{
+		"follower_id": followerID,
+		"followee_id": followeeID,
+	}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"_id":        NewID(),
+			"created_at": time.Now(),
+		},
+	}
+
+	res, err := s.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// Lost an upsert race with an identical request
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Only a new follow needs the followee's existing posts in the timeline
+	if s.fanout != nil && res.UpsertedCount > 0 {
+		if err := s.fanout.backfill(ctx, followerID, followeeID); err != nil {
+			slog.Warn("Backfilling timeline, older posts will be missing from the feed",
+				"follower_id", followerID.String(), "followee_id", followeeID.String(), "error", err)
+		}
+	}
+	return nil
+}
+
+// Unfollow removes the follow edge. Unfollowing a user that is not
+// followed is a no-op.
+func (s *FollowStore) Unfollow(ctx context.Context, followerID, followeeID ID) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{
+		"follower_id": followerID,
+		"followee_id": followeeID,
+	})
+	if err != nil || s.fanout == nil {
+		return err
+	}
+
+	return s.fanout.removeAuthor(ctx, followerID, followeeID)
+}
+
+// GetFollowers retrieves a page of users following userID, most recent first
+func (s *FollowStore) GetFollowers(ctx context.Context, userID ID, page Page) ([]FollowWithUser, *Cursor, error) {
+	return s.getWithUsers(ctx, bson.M{"followee_id": userID}, "follower_id", page)
+}
+
+// GetFollowing retrieves a page of users userID follows, most recent first
+func (s *FollowStore) GetFollowing(ctx context.Context, userID ID, page Page) ([]FollowWithUser, *Cursor, error) {
+	return s.getWithUsers(ctx, bson.M{"follower_id": userID}, "followee_id", page)
+}
+
+// GetCounts returns how many users follow userID and how many userID follows
+func (s *FollowStore) GetCounts(ctx context.Context, userID ID) (followers, following int64, err error) {
+	followers, err = s.collection.CountDocuments(ctx, bson.M{"followee_id": userID})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	following, err = s.collection.CountDocuments(ctx, bson.M{"follower_id": userID})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return followers, following, nil
+}
+
+// getWithUsers pages through follows matching filter and joins the user
+// referenced by userField
+func (s *FollowStore) getWithUsers(ctx context.Context, filter bson.M, userField string, page Page) ([]FollowWithUser, *Cursor, error) {
+	pipeline := []bson.M{
+		{
+			"$match": page.filter(filter),
+		},
+		{
+			"$sort": pageSort,
+		},
+		{
+			"$limit": page.fetchLimit(),
+		},
+		{
+			"$lookup": bson.M{
+				"from":         "users",
+				"localField":   userField,
+				"foreignField": "_id",
+				"as":           "user",
+			},
+		},
+		{
+			"$unwind": "$user",
+		},
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var follows []FollowWithUser
+	if err = cursor.All(ctx, &follows); err != nil {
+		return nil, nil, err
+	}
+
+	follows, next := trimPage(page, follows, FollowWithUser.cursor)
+	return follows, next, nil
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidID is returned by ParseID for malformed IDs
+var ErrInvalidID = errors.New("invalid id")
+
+// ID identifies a stored entity. IDs are opaque to clients; every backend
+// uses 24 hex character values that sort by creation time, so cursors and
+// URLs look the same regardless of the driver in use.
+type ID string
+
+// NewID returns a new unique ID
+func NewID() ID {
+	return ID(primitive.NewObjectID().Hex())
+}
+
+// ParseID validates s as an ID
+func ParseID(s string) (ID, error) {
+	if _, err := primitive.ObjectIDFromHex(s); err != nil {
+		return "", ErrInvalidID
+	}
+	return ID(s), nil
+}
+
+func (id ID) String() string {
+	return string(id)
+}
+
+// MarshalBSONValue stores well-formed IDs as native ObjectIDs so existing
+// Mongo documents and indexes keep working
+func (id ID) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if oid, err := primitive.ObjectIDFromHex(string(id)); err == nil {
+		return bson.MarshalValue(oid)
+	}
+	return bson.MarshalValue(string(id))
+}
+
+// UnmarshalBSONValue accepts ObjectIDs, strings and null
+func (id *ID) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.ObjectID:
+		*id = ID(raw.ObjectID().Hex())
+	case bsontype.String:
+		*id = ID(raw.StringValue())
+	case bsontype.Null, bsontype.Undefined:
+		*id = ""
+	default:
+		return fmt.Errorf("cannot decode %s into an ID", t)
+	}
+	return nil
+}
+
+// idsFromValues converts raw values returned by queries such as Distinct
+func idsFromValues(values []interface{}) []ID {
+	ids := make([]ID, 0, len(values))
+	for _, v := range values {
+		switch v := v.(type) {
+		case primitive.ObjectID:
+			ids = append(ids, ID(v.Hex()))
+		case string:
+			ids = append(ids, ID(v))
+		}
+	}
+	return ids
+}
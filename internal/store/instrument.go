@@ -0,0 +1,220 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// storeMetrics records the latency and errors of store operations
+type storeMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// Instrument returns s with its Posts and Users stores wrapped to record
+// operation latency and errors as Prometheus metrics registered with reg
+func Instrument(s Storage, reg prometheus.Registerer) Storage {
+	m := &storeMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gopherso_store_operation_duration_seconds",
+			Help:    "Latency of store operations by store and operation.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14), // 0.5ms to ~4s
+		}, []string{"store", "op"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopherso_store_operation_errors_total",
+			Help: "Store operations that returned an error, by store, operation and kind.",
+		}, []string{"store", "op", "kind"}),
+	}
+	reg.MustRegister(m.duration, m.errors)
+
+	inner := s
+	s.Posts = &instrumentedPosts{inner: inner, metrics: m}
+	s.Users = &instrumentedUsers{inner: inner, metrics: m}
+	return s
+}
+
+// observe records an operation that started at start and returned err
+func (m *storeMetrics) observe(store, op string, start time.Time, err error) {
+	m.duration.WithLabelValues(store, op).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(store, op, errorKind(err)).Inc()
+	}
+}
+
+// errorKind classifies err so expected outcomes such as a missing
+// document can be told apart from failures worth alerting on
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrConflict):
+		return "conflict"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrInvalidEmailChangeToken):
+		return "invalid"
+	case errors.Is(err, ErrUnavailable):
+		return "unavailable"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "internal"
+	}
+}
+
+// instrumentedPosts records metrics around the Posts store of inner
+type instrumentedPosts struct {
+	inner   Storage
+	metrics *storeMetrics
+}
+
+func (s *instrumentedPosts) Create(ctx context.Context, post *Post) error {
+	start := time.Now()
+	err := s.inner.Posts.Create(ctx, post)
+	s.metrics.observe("posts", "Create", start, err)
+	return err
+}
+
+func (s *instrumentedPosts) GetByID(ctx context.Context, id ID) (*Post, error) {
+	start := time.Now()
+	post, err := s.inner.Posts.GetByID(ctx, id)
+	s.metrics.observe("posts", "GetByID", start, err)
+	return post, err
+}
+
+func (s *instrumentedPosts) GetByUserID(ctx context.Context, userID ID, page Page) ([]Post, *Cursor, error) {
+	start := time.Now()
+	posts, cursor, err := s.inner.Posts.GetByUserID(ctx, userID, page)
+	s.metrics.observe("posts", "GetByUserID", start, err)
+	return posts, cursor, err
+}
+
+func (s *instrumentedPosts) GetWithUser(ctx context.Context, id ID) (*PostWithUser, error) {
+	start := time.Now()
+	post, err := s.inner.Posts.GetWithUser(ctx, id)
+	s.metrics.observe("posts", "GetWithUser", start, err)
+	return post, err
+}
+
+func (s *instrumentedPosts) GetAllWithUsers(ctx context.Context, page Page) ([]PostWithUser, *Cursor, error) {
+	start := time.Now()
+	posts, cursor, err := s.inner.Posts.GetAllWithUsers(ctx, page)
+	s.metrics.observe("posts", "GetAllWithUsers", start, err)
+	return posts, cursor, err
+}
+
+func (s *instrumentedPosts) GetFeed(ctx context.Context, userID ID, page Page) ([]PostWithUser, *Cursor, error) {
+	start := time.Now()
+	posts, cursor, err := s.inner.Posts.GetFeed(ctx, userID, page)
+	s.metrics.observe("posts", "GetFeed", start, err)
+	return posts, cursor, err
+}
+
+func (s *instrumentedPosts) GetByTag(ctx context.Context, tag string, page Page) ([]PostWithUser, *Cursor, error) {
+	start := time.Now()
+	posts, cursor, err := s.inner.Posts.GetByTag(ctx, tag, page)
+	s.metrics.observe("posts", "GetByTag", start, err)
+	return posts, cursor, err
+}
+
+func (s *instrumentedPosts) Search(ctx context.Context, search PostSearch) ([]PostSearchResult, error) {
+	start := time.Now()
+	results, err := s.inner.Posts.Search(ctx, search)
+	s.metrics.observe("posts", "Search", start, err)
+	return results, err
+}
+
+func (s *instrumentedPosts) Update(ctx context.Context, postID, userID ID, update PostUpdate) error {
+	start := time.Now()
+	err := s.inner.Posts.Update(ctx, postID, userID, update)
+	s.metrics.observe("posts", "Update", start, err)
+	return err
+}
+
+func (s *instrumentedPosts) Delete(ctx context.Context, postID, userID ID) error {
+	start := time.Now()
+	err := s.inner.Posts.Delete(ctx, postID, userID)
+	s.metrics.observe("posts", "Delete", start, err)
+	return err
+}
+
+// instrumentedUsers records metrics around the Users store of inner
+type instrumentedUsers struct {
+	inner   Storage
+	metrics *storeMetrics
+}
+
+func (s *instrumentedUsers) Create(ctx context.Context, user *User) error {
+	start := time.Now()
+	err := s.inner.Users.Create(ctx, user)
+	s.metrics.observe("users", "Create", start, err)
+	return err
+}
+
+func (s *instrumentedUsers) GetByID(ctx context.Context, id ID) (*User, error) {
+	start := time.Now()
+	user, err := s.inner.Users.GetByID(ctx, id)
+	s.metrics.observe("users", "GetByID", start, err)
+	return user, err
+}
+
+func (s *instrumentedUsers) GetByEmail(ctx context.Context, email string) (*User, error) {
+	start := time.Now()
+	user, err := s.inner.Users.GetByEmail(ctx, email)
+	s.metrics.observe("users", "GetByEmail", start, err)
+	return user, err
+}
+
+func (s *instrumentedUsers) GetWithPosts(ctx context.Context, id ID, page Page) (*UserWithPosts, *Cursor, error) {
+	start := time.Now()
+	user, cursor, err := s.inner.Users.GetWithPosts(ctx, id, page)
+	s.metrics.observe("users", "GetWithPosts", start, err)
+	return user, cursor, err
+}
+
+func (s *instrumentedUsers) GetPostsCount(ctx context.Context, id ID) (int64, error) {
+	start := time.Now()
+	count, err := s.inner.Users.GetPostsCount(ctx, id)
+	s.metrics.observe("users", "GetPostsCount", start, err)
+	return count, err
+}
+
+func (s *instrumentedUsers) VerifyCredentials(ctx context.Context, email, password string) (*User, error) {
+	start := time.Now()
+	user, err := s.inner.Users.VerifyCredentials(ctx, email, password)
+	s.metrics.observe("users", "VerifyCredentials", start, err)
+	return user, err
+}
+
+func (s *instrumentedUsers) Update(ctx context.Context, id ID, update UserUpdate) error {
+	start := time.Now()
+	err := s.inner.Users.Update(ctx, id, update)
+	s.metrics.observe("users", "Update", start, err)
+	return err
+}
+
+func (s *instrumentedUsers) RequestEmailChange(ctx context.Context, userID ID, newEmail, tokenHash string, expiresAt time.Time) error {
+	start := time.Now()
+	err := s.inner.Users.RequestEmailChange(ctx, userID, newEmail, tokenHash, expiresAt)
+	s.metrics.observe("users", "RequestEmailChange", start, err)
+	return err
+}
+
+func (s *instrumentedUsers) ConfirmEmailChange(ctx context.Context, tokenHash string) (*User, error) {
+	start := time.Now()
+	user, err := s.inner.Users.ConfirmEmailChange(ctx, tokenHash)
+	s.metrics.observe("users", "ConfirmEmailChange", start, err)
+	return user, err
+}
+
+func (s *instrumentedUsers) Delete(ctx context.Context, id ID) error {
+	start := time.Now()
+	err := s.inner.Users.Delete(ctx, id)
+	s.metrics.observe("users", "Delete", start, err)
+	return err
+}
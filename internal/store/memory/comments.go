@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+type CommentStore struct {
+	*data
+}
+
+// Create adds a comment to a post, or a reply when ParentID is set, and
+// bumps the post's and parent's counters
+func (s *CommentStore) Create(ctx context.Context, comment *store.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment.Ancestors = []store.ID{}
+	comment.Depth = 0
+
+	var parent store.Comment
+	if comment.ParentID != nil {
+		var ok bool
+		parent, ok = s.comments[*comment.ParentID]
+		if !ok {
+			return store.ErrNotFound
+		}
+		if parent.PostID != comment.PostID {
+			return store.ErrParentMismatch
+		}
+		comment.Ancestors = append(append(comment.Ancestors, parent.Ancestors...), parent.ID)
+		comment.Depth = parent.Depth + 1
+	}
+
+	comment.ID = store.NewID()
+	comment.RepliesCount = 0
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = time.Now()
+
+	s.comments[comment.ID] = cloneComment(*comment)
+
+	if post, ok := s.posts[comment.PostID]; ok {
+		post.CommentsCount++
+		s.posts[post.ID] = post
+	}
+
+	if comment.ParentID != nil {
+		parent.RepliesCount++
+		s.comments[parent.ID] = parent
+	}
+
+	return nil
+}
+
+// GetByID retrieves a comment by its ID
+func (s *CommentStore) GetByID(ctx context.Context, commentID store.ID) (*store.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comment, ok := s.comments[commentID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	comment = cloneComment(comment)
+	return &comment, nil
+}
+
+// GetByPostID retrieves a page of comments on a post with their authors,
+// newest first. With a nil parentID only top-level comments are returned,
+// otherwise the direct replies to that comment.
+func (s *CommentStore) GetByPostID(ctx context.Context, postID store.ID, parentID *store.ID, page store.Page) ([]store.CommentWithUser, *store.Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := []store.CommentWithUser{}
+	for _, c := range s.comments {
+		if c.PostID != postID {
+			continue
+		}
+		if (parentID == nil) != (c.ParentID == nil) || (parentID != nil && *parentID != *c.ParentID) {
+			continue
+		}
+
+		result := store.CommentWithUser{Comment: cloneComment(c)}
+		// Keep comments by deleted users
+		if user, ok := s.users[c.UserID]; ok {
+			user = cloneUser(user)
+			result.User = &user
+		}
+		comments = append(comments, result)
+	}
+
+	comments, next := paginate(comments, page, func(c store.CommentWithUser) store.Cursor {
+		return store.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+	return comments, next, nil
+}
+
+// Update changes the content of a comment (only by its author)
+func (s *CommentStore) Update(ctx context.Context, commentID, userID store.ID, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.comments[commentID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if comment.UserID != userID {
+		return store.ErrForbidden
+	}
+
+	comment.Content = content
+	comment.UpdatedAt = time.Now()
+	s.comments[commentID] = comment
+	return nil
+}
+
+// Delete deletes a comment and all replies below it. Only the comment's
+// author or the owner of the post may delete it.
+func (s *CommentStore) Delete(ctx context.Context, commentID, userID store.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.comments[commentID]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	post, postExists := s.posts[comment.PostID]
+	if comment.UserID != userID && post.UserID != userID {
+		return store.ErrForbidden
+	}
+
+	var deleted int64
+	for id, c := range s.comments {
+		if id == commentID || containsID(c.Ancestors, commentID) {
+			delete(s.comments, id)
+			deleted++
+		}
+	}
+
+	if postExists {
+		post.CommentsCount -= deleted
+		s.posts[post.ID] = post
+	}
+
+	if comment.ParentID != nil {
+		if parent, ok := s.comments[*comment.ParentID]; ok {
+			parent.RepliesCount--
+			s.comments[parent.ID] = parent
+		}
+	}
+
+	return nil
+}
+
+// containsID reports whether ids contains id
+func containsID(ids []store.ID, id store.ID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
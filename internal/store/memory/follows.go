@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+type FollowStore struct {
+	*data
+}
+
+// Follow makes followerID follow followeeID. Following twice is a no-op.
+func (s *FollowStore) Follow(ctx context.Context, followerID, followeeID store.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findFollow(followerID, followeeID) != "" {
+		return nil
+	}
+
+	id := store.NewID()
+	s.follows[id] = store.Follow{
+		ID:         id,
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now(),
+	}
+	return nil
+}
+
+// Unfollow removes the follow edge. Unfollowing a user that is not
+// followed is a no-op.
+func (s *FollowStore) Unfollow(ctx context.Context, followerID, followeeID store.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.follows, s.findFollow(followerID, followeeID))
+	return nil
+}
+
+// findFollow returns the ID of the edge from followerID to followeeID, or
+// the empty ID if there is none. The caller must hold the lock.
+func (s *FollowStore) findFollow(followerID, followeeID store.ID) store.ID {
+	for id, f := range s.follows {
+		if f.FollowerID == followerID && f.FolloweeID == followeeID {
+			return id
+		}
+	}
+	return ""
+}
+
+// GetFollowers retrieves a page of users following userID, most recent first
+func (s *FollowStore) GetFollowers(ctx context.Context, userID store.ID, page store.Page) ([]store.FollowWithUser, *store.Cursor, error) {
+	return s.getWithUsers(func(f store.Follow) (store.ID, bool) {
+		return f.FollowerID, f.FolloweeID == userID
+	}, page)
+}
+
+// GetFollowing retrieves a page of users userID follows, most recent first
+func (s *FollowStore) GetFollowing(ctx context.Context, userID store.ID, page store.Page) ([]store.FollowWithUser, *store.Cursor, error) {
+	return s.getWithUsers(func(f store.Follow) (store.ID, bool) {
+		return f.FolloweeID, f.FollowerID == userID
+	}, page)
+}
+
+// GetCounts returns how many users follow userID and how many userID follows
+func (s *FollowStore) GetCounts(ctx context.Context, userID store.ID) (followers, following int64, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, f := range s.follows {
+		if f.FolloweeID == userID {
+			followers++
+		}
+		if f.FollowerID == userID {
+			following++
+		}
+	}
+	return followers, following, nil
+}
+
+// getWithUsers pages through the follows accepted by match and joins the
+// user match returns for each. Edges to deleted users are skipped.
+func (s *FollowStore) getWithUsers(match func(store.Follow) (store.ID, bool), page store.Page) ([]store.FollowWithUser, *store.Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	follows := []store.FollowWithUser{}
+	for _, f := range s.follows {
+		userID, ok := match(f)
+		if !ok {
+			continue
+		}
+		user, ok := s.users[userID]
+		if !ok {
+			continue
+		}
+		follows = append(follows, store.FollowWithUser{Follow: f, User: cloneUser(user)})
+	}
+
+	follows, next := paginate(follows, page, func(f store.FollowWithUser) store.Cursor {
+		return store.Cursor{CreatedAt: f.CreatedAt, ID: f.ID}
+	})
+	return follows, next, nil
+}
@@ -0,0 +1,291 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/search"
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+// Search weights of post fields, matching the Mongo text index
+const (
+	titleWeight   = 3
+	contentWeight = 1
+)
+
+// postCursor returns the pagination position of a post
+func postCursor(p store.Post) store.Cursor {
+	return store.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+type PostStore struct {
+	*data
+}
+
+func (s *PostStore) Create(ctx context.Context, post *store.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post.ID = store.NewID()
+	post.CommentsCount = 0
+	post.CreatedAt = time.Now()
+	post.UpdatedAt = time.Now()
+
+	s.posts[post.ID] = clonePost(*post)
+	return nil
+}
+
+// GetByID retrieves a post by its ID
+func (s *PostStore) GetByID(ctx context.Context, postID store.ID) (*store.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	post, ok := s.posts[postID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	post = clonePost(post)
+	return &post, nil
+}
+
+// GetByUserID retrieves a page of posts by a specific user, newest first
+func (s *PostStore) GetByUserID(ctx context.Context, userID store.ID, page store.Page) ([]store.Post, *store.Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts, next := s.postsBy(userID, page)
+	return posts, next, nil
+}
+
+// postsBy returns a page of posts by userID, newest first. The caller must
+// hold the lock.
+func (d *data) postsBy(userID store.ID, page store.Page) ([]store.Post, *store.Cursor) {
+	posts := []store.Post{}
+	for _, post := range d.posts {
+		if post.UserID == userID {
+			posts = append(posts, clonePost(post))
+		}
+	}
+	return paginate(posts, page, postCursor)
+}
+
+// GetWithUser retrieves a post with user information
+func (s *PostStore) GetWithUser(ctx context.Context, postID store.ID) (*store.PostWithUser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	post, ok := s.posts[postID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	user, ok := s.users[post.UserID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	return &store.PostWithUser{
+		Post: clonePost(post),
+		User: cloneUser(user),
+	}, nil
+}
+
+// GetAllWithUsers retrieves a page of posts with user information, newest first
+func (s *PostStore) GetAllWithUsers(ctx context.Context, page store.Page) ([]store.PostWithUser, *store.Cursor, error) {
+	return s.listWithUsers(func(store.Post) bool { return true }, page)
+}
+
+// GetByTag retrieves a page of posts carrying tag with user information, newest first
+func (s *PostStore) GetByTag(ctx context.Context, tag string, page store.Page) ([]store.PostWithUser, *store.Cursor, error) {
+	return s.listWithUsers(func(p store.Post) bool { return hasAllTags(p, []string{tag}) }, page)
+}
+
+// GetFeed retrieves a page of the home timeline of userID: posts by the
+// user and everyone they follow, newest first, with user information
+func (s *PostStore) GetFeed(ctx context.Context, userID store.ID, page store.Page) ([]store.PostWithUser, *store.Cursor, error) {
+	s.mu.RLock()
+	authors := map[store.ID]bool{userID: true}
+	for _, f := range s.follows {
+		if f.FollowerID == userID {
+			authors[f.FolloweeID] = true
+		}
+	}
+	s.mu.RUnlock()
+
+	return s.listWithUsers(func(p store.Post) bool { return authors[p.UserID] }, page)
+}
+
+// listWithUsers pages through posts matching keep and joins each with its
+// author. Posts whose author no longer exists are skipped.
+func (s *PostStore) listWithUsers(keep func(store.Post) bool, page store.Page) ([]store.PostWithUser, *store.Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := []store.PostWithUser{}
+	for _, post := range s.posts {
+		user, ok := s.users[post.UserID]
+		if !ok || !keep(post) {
+			continue
+		}
+		posts = append(posts, store.PostWithUser{Post: clonePost(post), User: cloneUser(user)})
+	}
+
+	posts, next := paginate(posts, page, func(p store.PostWithUser) store.Cursor { return postCursor(p.Post) })
+	return posts, next, nil
+}
+
+// Search runs a full-text search over post titles and content. Words match
+// on prefixes, "quoted phrases" must all match and -words exclude posts.
+func (s *PostStore) Search(ctx context.Context, q store.PostSearch) ([]store.PostSearchResult, error) {
+	terms, excluded := search.Parse(q.Text)
+	var phrases []string
+	for _, term := range terms {
+		if strings.Contains(term, " ") {
+			phrases = append(phrases, term)
+		}
+	}
+
+	authors := make(map[store.ID]bool, len(q.AuthorIDs))
+	for _, id := range q.AuthorIDs {
+		authors[id] = true
+	}
+
+	s.mu.RLock()
+	results := []store.PostSearchResult{}
+	for _, post := range s.posts {
+		user, ok := s.users[post.UserID]
+		if !ok ||
+			!hasAllTags(post, q.Tags) ||
+			(len(authors) > 0 && !authors[post.UserID]) ||
+			(q.From != nil && post.CreatedAt.Before(*q.From)) ||
+			(q.To != nil && !post.CreatedAt.Before(*q.To)) {
+			continue
+		}
+
+		text := strings.ToLower(post.Title + "\n" + post.Content)
+		if search.Count(text, excluded) > 0 || !containsAll(text, phrases) {
+			continue
+		}
+		score := titleWeight*search.Count(post.Title, terms) + contentWeight*search.Count(post.Content, terms)
+		if score == 0 {
+			continue
+		}
+
+		results = append(results, store.PostSearchResult{
+			PostWithUser: store.PostWithUser{Post: clonePost(post), User: cloneUser(user)},
+			Score:        float64(score),
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if q.Sort != store.SortRecent && a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if q.Sort == store.SortRecent && !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+
+	if q.Offset >= int64(len(results)) {
+		return []store.PostSearchResult{}, nil
+	}
+	results = results[q.Offset:]
+	if int64(len(results)) > q.Limit {
+		results = results[:q.Limit]
+	}
+
+	return results, nil
+}
+
+// hasAllTags reports whether post carries every one of tags
+func hasAllTags(post store.Post, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range post.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAll reports whether text contains every one of substrings
+func containsAll(text string, substrings []string) bool {
+	for _, sub := range substrings {
+		if !strings.Contains(text, sub) {
+			return false
+		}
+	}
+	return true
+}
+
+// Update updates a post (only by the owner)
+func (s *PostStore) Update(ctx context.Context, postID, userID store.ID, changes store.PostUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.posts[postID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if post.UserID != userID {
+		return store.ErrForbidden
+	}
+
+	if changes.Title != nil {
+		post.Title = *changes.Title
+	}
+	if changes.Content != nil {
+		post.Content = *changes.Content
+	}
+	if changes.Tags != nil {
+		post.Tags = append([]string{}, *changes.Tags...)
+	}
+	post.UpdatedAt = time.Now()
+
+	s.posts[postID] = post
+	return nil
+}
+
+// Delete deletes a post with its comments and reactions (only by the owner)
+func (s *PostStore) Delete(ctx context.Context, postID, userID store.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.posts[postID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if post.UserID != userID {
+		return store.ErrForbidden
+	}
+
+	s.deletePost(postID)
+	return nil
+}
+
+// deletePost removes a post with its comments and reactions. The caller
+// must hold the write lock.
+func (d *data) deletePost(postID store.ID) {
+	delete(d.posts, postID)
+	for id, c := range d.comments {
+		if c.PostID == postID {
+			delete(d.comments, id)
+		}
+	}
+	for id, r := range d.reactions {
+		if r.PostID == postID {
+			delete(d.reactions, id)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+type ReactionStore struct {
+	*data
+}
+
+// Add records a reaction and increments the post's counter. It reports
+// false without changing anything if the reaction already exists.
+func (s *ReactionStore) Add(ctx context.Context, postID, userID store.ID, kind string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findReaction(postID, userID, kind) != "" {
+		return false, nil
+	}
+
+	post, ok := s.posts[postID]
+	if !ok {
+		return false, store.ErrNotFound
+	}
+
+	id := store.NewID()
+	s.reactions[id] = store.Reaction{
+		ID:        id,
+		PostID:    postID,
+		UserID:    userID,
+		Kind:      kind,
+		CreatedAt: time.Now(),
+	}
+
+	if post.Reactions == nil {
+		post.Reactions = make(map[string]int64)
+	}
+	post.Reactions[kind]++
+	s.posts[postID] = post
+
+	return true, nil
+}
+
+// Remove deletes a reaction and decrements the post's counter. It reports
+// false without changing anything if there was no such reaction.
+func (s *ReactionStore) Remove(ctx context.Context, postID, userID store.ID, kind string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.findReaction(postID, userID, kind)
+	if id == "" {
+		return false, nil
+	}
+	delete(s.reactions, id)
+
+	if post, ok := s.posts[postID]; ok && post.Reactions != nil {
+		post.Reactions[kind]--
+	}
+
+	return true, nil
+}
+
+// findReaction returns the ID of userID's reaction of kind on postID, or
+// the empty ID if there is none. The caller must hold the lock.
+func (s *ReactionStore) findReaction(postID, userID store.ID, kind string) store.ID {
+	for id, r := range s.reactions {
+		if r.PostID == postID && r.UserID == userID && r.Kind == kind {
+			return id
+		}
+	}
+	return ""
+}
+
+// GetKindsByUser returns the kinds of reaction userID left on each of postIDs
+func (s *ReactionStore) GetKindsByUser(ctx context.Context, postIDs []store.ID, userID store.ID) (map[store.ID][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[store.ID]bool, len(postIDs))
+	for _, id := range postIDs {
+		wanted[id] = true
+	}
+
+	var reactions []store.Reaction
+	for _, r := range s.reactions {
+		if r.UserID == userID && wanted[r.PostID] {
+			reactions = append(reactions, r)
+		}
+	}
+	sort.Slice(reactions, func(i, j int) bool {
+		return reactions[i].CreatedAt.Before(reactions[j].CreatedAt)
+	})
+
+	kinds := make(map[store.ID][]string, len(postIDs))
+	for _, r := range reactions {
+		kinds[r.PostID] = append(kinds[r.PostID], r.Kind)
+	}
+	return kinds, nil
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+type SessionStore struct {
+	*data
+}
+
+// Create inserts a new session. The ID may be preset by the caller since it
+// is embedded in the refresh token whose hash is stored alongside it.
+func (s *SessionStore) Create(ctx context.Context, session *store.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session.ID == "" {
+		session.ID = store.NewID()
+	}
+	if _, ok := s.sessions[session.ID]; ok {
+		return &store.DuplicateError{Field: "_id"}
+	}
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = session.CreatedAt
+	session.PreviousTokenHashes = []string{}
+
+	s.sessions[session.ID] = cloneSession(*session)
+	return nil
+}
+
+// GetByID retrieves a session by its ID
+func (s *SessionStore) GetByID(ctx context.Context, sessionID store.ID) (*store.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	session = cloneSession(session)
+	return &session, nil
+}
+
+// GetActiveByUserID retrieves all live sessions of a user, most recently used first
+func (s *SessionStore) GetActiveByUserID(ctx context.Context, userID store.ID) ([]store.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	sessions := []store.Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, cloneSession(session))
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// Rotate replaces the session's current refresh token hash with newHash,
+// provided tokenHash is the current one. Presenting an already rotated
+// token revokes the session and returns store.ErrRefreshTokenReused.
+func (s *SessionStore) Rotate(ctx context.Context, sessionID store.ID, tokenHash, newHash string, expiresAt time.Time) (*store.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, store.ErrSessionInvalid
+	}
+
+	if session.TokenHash != tokenHash || session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		if session.RevokedAt == nil && session.TokenHash != tokenHash && session.HasTokenHash(tokenHash) {
+			// A rotated token came back: either the client or an attacker
+			// holds a stolen copy, so kill the whole family.
+			session.RevokedAt = &now
+			s.sessions[sessionID] = session
+			return nil, store.ErrRefreshTokenReused
+		}
+		return nil, store.ErrSessionInvalid
+	}
+
+	previous := append(session.PreviousTokenHashes, tokenHash)
+	if len(previous) > store.MaxPreviousTokenHashes {
+		previous = previous[len(previous)-store.MaxPreviousTokenHashes:]
+	}
+
+	session.TokenHash = newHash
+	session.PreviousTokenHashes = append([]string{}, previous...)
+	session.LastUsedAt = now
+	session.ExpiresAt = expiresAt
+
+	s.sessions[sessionID] = session
+	session = cloneSession(session)
+	return &session, nil
+}
+
+// Revoke revokes a session (only by its owner)
+func (s *SessionStore) Revoke(ctx context.Context, sessionID, userID store.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return store.ErrNotFound
+	}
+
+	now := time.Now()
+	session.RevokedAt = &now
+	s.sessions[sessionID] = session
+	return nil
+}
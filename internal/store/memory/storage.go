@@ -0,0 +1,122 @@
+// Package memory implements store.Storage in process memory. It is meant
+// for tests and local development: nothing is persisted and every store
+// shares a single lock.
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+// data holds every entity behind one lock so operations spanning several
+// of them, such as cascading deletes, are atomic. Entities are stored by
+// value and copied on the way in and out so callers never share memory
+// with the store.
+type data struct {
+	mu        sync.RWMutex
+	users     map[store.ID]store.User
+	posts     map[store.ID]store.Post
+	sessions  map[store.ID]store.Session
+	follows   map[store.ID]store.Follow
+	comments  map[store.ID]store.Comment
+	reactions map[store.ID]store.Reaction
+}
+
+// NewStorage returns empty in-memory stores. Home feeds are built at read
+// time; fan-out timelines are only available on Mongo.
+func NewStorage() store.Storage {
+	d := &data{
+		users:     make(map[store.ID]store.User),
+		posts:     make(map[store.ID]store.Post),
+		sessions:  make(map[store.ID]store.Session),
+		follows:   make(map[store.ID]store.Follow),
+		comments:  make(map[store.ID]store.Comment),
+		reactions: make(map[store.ID]store.Reaction),
+	}
+
+	return store.Storage{
+		Users:     &UserStore{data: d},
+		Posts:     &PostStore{data: d},
+		Sessions:  &SessionStore{data: d},
+		Comments:  &CommentStore{data: d},
+		Reactions: &ReactionStore{data: d},
+		Tags:      &TagStore{data: d},
+		Follows:   &FollowStore{data: d},
+	}
+}
+
+// newer reports whether a sorts before b in listings, which are ordered by
+// created_at and ID, both descending
+func newer(a, b store.Cursor) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+// paginate sorts items newest first, drops those up to the page cursor and
+// cuts the rest down to the page size. It returns the cursor of the next
+// page, or nil when this is the last one.
+func paginate[T any](items []T, page store.Page, cursorOf func(T) store.Cursor) ([]T, *store.Cursor) {
+	sort.Slice(items, func(i, j int) bool {
+		return newer(cursorOf(items[i]), cursorOf(items[j]))
+	})
+
+	if page.After != nil {
+		start := sort.Search(len(items), func(i int) bool {
+			return newer(*page.After, cursorOf(items[i]))
+		})
+		items = items[start:]
+	}
+
+	if int64(len(items)) <= page.Limit {
+		return items, nil
+	}
+	items = items[:page.Limit]
+	next := cursorOf(items[len(items)-1])
+	return items, &next
+}
+
+// clonePost returns a copy of p that shares no memory with it
+func clonePost(p store.Post) store.Post {
+	p.Tags = append([]string{}, p.Tags...)
+	if p.Reactions != nil {
+		reactions := make(map[string]int64, len(p.Reactions))
+		for kind, count := range p.Reactions {
+			reactions[kind] = count
+		}
+		p.Reactions = reactions
+	}
+	return p
+}
+
+// cloneUser returns a copy of u that shares no memory with it
+func cloneUser(u store.User) store.User {
+	if u.EmailChangeExpiresAt != nil {
+		expiresAt := *u.EmailChangeExpiresAt
+		u.EmailChangeExpiresAt = &expiresAt
+	}
+	return u
+}
+
+// cloneSession returns a copy of s that shares no memory with it
+func cloneSession(s store.Session) store.Session {
+	s.PreviousTokenHashes = append([]string{}, s.PreviousTokenHashes...)
+	if s.RevokedAt != nil {
+		revokedAt := *s.RevokedAt
+		s.RevokedAt = &revokedAt
+	}
+	return s
+}
+
+// cloneComment returns a copy of c that shares no memory with it
+func cloneComment(c store.Comment) store.Comment {
+	c.Ancestors = append([]store.ID{}, c.Ancestors...)
+	if c.ParentID != nil {
+		parentID := *c.ParentID
+		c.ParentID = &parentID
+	}
+	return c
+}
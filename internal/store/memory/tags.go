@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+type TagStore struct {
+	*data
+}
+
+// GetTrending returns the limit most used tags on posts created within
+// window, most used first
+func (s *TagStore) GetTrending(ctx context.Context, window time.Duration, limit int64) ([]store.TagCount, error) {
+	s.mu.RLock()
+	since := time.Now().Add(-window)
+	counts := make(map[string]int64)
+	for _, post := range s.posts {
+		if post.CreatedAt.Before(since) {
+			continue
+		}
+		for _, tag := range post.Tags {
+			counts[tag]++
+		}
+	}
+	s.mu.RUnlock()
+
+	tags := make([]store.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, store.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	if int64(len(tags)) > limit {
+		tags = tags[:limit]
+	}
+	return tags, nil
+}
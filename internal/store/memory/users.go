@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+type UserStore struct {
+	*data
+}
+
+func (s *UserStore) Create(ctx context.Context, user *store.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkUnique("", user.Email, user.Username); err != nil {
+		return err
+	}
+
+	user.ID = store.NewID()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	s.users[user.ID] = cloneUser(*user)
+	return nil
+}
+
+// checkUnique returns a *store.DuplicateError if a user other than userID
+// already has email or username, ignoring case. Empty values are not
+// checked. The caller must hold the lock.
+func (s *UserStore) checkUnique(userID store.ID, email, username string) error {
+	for _, u := range s.users {
+		if u.ID == userID {
+			continue
+		}
+		if email != "" && strings.EqualFold(u.Email, email) {
+			return &store.DuplicateError{Field: "email"}
+		}
+		if username != "" && strings.EqualFold(u.Username, username) {
+			return &store.DuplicateError{Field: "username"}
+		}
+	}
+	return nil
+}
+
+// GetByID retrieves a user by their ID
+func (s *UserStore) GetByID(ctx context.Context, userID store.ID) (*store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	user = cloneUser(user)
+	return &user, nil
+}
+
+// GetByEmail retrieves a user by email, ignoring case
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if strings.EqualFold(user.Email, email) {
+			user = cloneUser(user)
+			return &user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+// GetWithPosts retrieves a user with a page of their posts, newest first
+func (s *UserStore) GetWithPosts(ctx context.Context, userID store.ID, page store.Page) (*store.UserWithPosts, *store.Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, nil, store.ErrNotFound
+	}
+
+	posts, next := s.postsBy(userID, page)
+	return &store.UserWithPosts{
+		User:  cloneUser(user),
+		Posts: posts,
+	}, next, nil
+}
+
+// GetPostsCount returns the number of posts for a user
+func (s *UserStore) GetPostsCount(ctx context.Context, userID store.ID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, post := range s.posts {
+		if post.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// VerifyCredentials returns the user matching email if password is correct
+func (s *UserStore) VerifyCredentials(ctx context.Context, email, password string) (*store.User, error) {
+	return store.CheckCredentials(ctx, email, password, s.GetByEmail, s.updatePasswordIfUnchanged)
+}
+
+// updatePasswordIfUnchanged replaces the user's password hash if it is still oldHash
+func (s *UserStore) updatePasswordIfUnchanged(ctx context.Context, userID store.ID, oldHash, newHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[userID]; ok && user.Password == oldHash {
+		user.Password = newHash
+		s.users[userID] = user
+	}
+	return nil
+}
+
+// Update updates a user's profile
+func (s *UserStore) Update(ctx context.Context, userID store.ID, changes store.UserUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	if changes.Username != nil {
+		if err := s.checkUnique(userID, "", *changes.Username); err != nil {
+			return err
+		}
+		user.Username = *changes.Username
+	}
+	if changes.Bio != nil {
+		user.Bio = *changes.Bio
+	}
+	user.UpdatedAt = time.Now()
+
+	s.users[userID] = user
+	return nil
+}
+
+// RequestEmailChange records newEmail as pending until the token whose hash
+// is tokenHash is confirmed. Any earlier pending change is replaced.
+func (s *UserStore) RequestEmailChange(ctx context.Context, userID store.ID, newEmail, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	user.PendingEmail = newEmail
+	user.EmailChangeTokenHash = tokenHash
+	user.EmailChangeExpiresAt = &expiresAt
+
+	s.users[userID] = user
+	return nil
+}
+
+// ConfirmEmailChange swaps in the pending email of the user holding
+// tokenHash. The token can only be used once.
+func (s *UserStore) ConfirmEmailChange(ctx context.Context, tokenHash string) (*store.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, user := range s.users {
+		if user.EmailChangeTokenHash != tokenHash || user.EmailChangeExpiresAt == nil || !user.EmailChangeExpiresAt.After(now) {
+			continue
+		}
+
+		if err := s.checkUnique(user.ID, user.PendingEmail, ""); err != nil {
+			return nil, err
+		}
+
+		user.Email = user.PendingEmail
+		user.PendingEmail = ""
+		user.EmailChangeTokenHash = ""
+		user.EmailChangeExpiresAt = nil
+		user.UpdatedAt = now
+
+		s.users[user.ID] = user
+		return &user, nil
+	}
+
+	return nil, store.ErrInvalidEmailChangeToken
+}
+
+// Delete deletes a user and everything referencing them under one lock
+func (s *UserStore) Delete(ctx context.Context, userID store.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+
+	for id, post := range s.posts {
+		if post.UserID == userID {
+			s.deletePost(id)
+		}
+	}
+
+	for id, r := range s.reactions {
+		if r.UserID != userID {
+			continue
+		}
+		post := s.posts[r.PostID]
+		if post.Reactions != nil {
+			post.Reactions[r.Kind]--
+		}
+		delete(s.reactions, id)
+	}
+
+	for id, c := range s.comments {
+		if c.UserID == userID {
+			c.UserID = ""
+			c.Content = ""
+			s.comments[id] = c
+		}
+	}
+
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, id)
+		}
+	}
+
+	for id, f := range s.follows {
+		if f.FollowerID == userID || f.FolloweeID == userID {
+			delete(s.follows, id)
+		}
+	}
+
+	delete(s.users, userID)
+	return nil
+}
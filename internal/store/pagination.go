@@ -0,0 +1,90 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor identifies a position in a listing sorted by created_at and _id,
+// both descending. The _id breaks ties between equal timestamps.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        ID
+}
+
+// Page requests up to Limit items strictly after the After cursor, or from
+// the start of the listing when After is nil
+type Page struct {
+	Limit int64
+	After *Cursor
+}
+
+type cursorJSON struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(cursorJSON{CreatedAt: c.CreatedAt, ID: string(c.ID)})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor produced by Cursor.Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursorJSON
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := ParseID(c.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{CreatedAt: c.CreatedAt, ID: id}, nil
+}
+
+// pageSort is the sort order every cursor-paginated listing uses
+var pageSort = bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+
+// filter adds the "after cursor" condition to filter, if any
+func (p Page) filter(filter bson.M) bson.M {
+	if p.After == nil {
+		return filter
+	}
+	filter["$or"] = bson.A{
+		bson.M{"created_at": bson.M{"$lt": p.After.CreatedAt}},
+		bson.M{"created_at": p.After.CreatedAt, "_id": bson.M{"$lt": p.After.ID}},
+	}
+	return filter
+}
+
+// fetchLimit is one more than the page size so callers can tell whether
+// another page exists
+func (p Page) fetchLimit() int64 {
+	return p.Limit + 1
+}
+
+// trimPage cuts items down to the page size and returns the cursor of the
+// next page, or nil when this is the last one
+func trimPage[T any](p Page, items []T, cursorOf func(T) Cursor) ([]T, *Cursor) {
+	if int64(len(items)) <= p.Limit {
+		return items, nil
+	}
+	items = items[:p.Limit]
+	next := cursorOf(items[len(items)-1])
+	return items, &next
+}
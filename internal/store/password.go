@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Stored password hashes are prefixed with a scheme version so the
+// algorithm or its cost can evolve without invalidating existing accounts:
+//
+//	v1$<bcrypt hash>
+//
+// Anything without a known prefix is treated as a legacy plaintext value
+// written before hashing was introduced.
+const (
+	passwordVersionV1 = "v1$"
+
+	// passwordCost is the bcrypt work factor for new hashes. Raising it makes
+	// older hashes eligible for rehashing on the next successful login.
+	passwordCost = 12
+)
+
+// ErrPasswordTooLong is returned when a password is longer than the 72
+// bytes bcrypt can hash
+var ErrPasswordTooLong = errors.New("password too long")
+
+var (
+	errUnknownPasswordHash = errors.New("unknown password hash format")
+	versionedHash          = regexp.MustCompile(`^v[0-9]+\$`)
+)
+
+// dummyPasswordHash is compared against when no user matches an email so
+// that lookups for unknown accounts take as long as real ones.
+var dummyPasswordHash, _ = HashPassword("gopherso-dummy-password")
+
+// HashPassword hashes a plaintext password using the current scheme version.
+func HashPassword(plaintext string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plaintext), passwordCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", ErrPasswordTooLong
+	}
+	if err != nil {
+		return "", err
+	}
+	return passwordVersionV1 + string(hash), nil
+}
+
+// SetPassword hashes plaintext and stores the result on the user
+func (u *User) SetPassword(plaintext string) error {
+	hash, err := HashPassword(plaintext)
+	if err != nil {
+		return err
+	}
+	u.Password = hash
+	return nil
+}
+
+// VerifyPassword reports whether plaintext matches the user's stored hash
+// and whether the hash should be replaced with one using the current scheme.
+// A nil user is checked against a dummy hash so that lookups for unknown
+// accounts take as long as real ones.
+func VerifyPassword(user *User, plaintext string) (match, needsRehash bool, err error) {
+	if user == nil {
+		comparePassword(dummyPasswordHash, plaintext)
+		return false, false, nil
+	}
+	return comparePassword(user.Password, plaintext)
+}
+
+// CheckCredentials implements Users.VerifyCredentials on top of a backend's
+// lookup by email. On success, a hash using an outdated scheme or cost is
+// replaced through updatePasswordIfUnchanged, which must only overwrite
+// oldHash so that a password changed meanwhile is kept.
+func CheckCredentials(
+	ctx context.Context,
+	email, password string,
+	getByEmail func(ctx context.Context, email string) (*User, error),
+	updatePasswordIfUnchanged func(ctx context.Context, userID ID, oldHash, newHash string) error,
+) (*User, error) {
+	user, err := getByEmail(ctx, email)
+	if errors.Is(err, ErrNotFound) {
+		// Burn the same amount of time as a real comparison
+		VerifyPassword(nil, password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	match, needsRehash, err := VerifyPassword(user, password)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, ErrInvalidCredentials
+	}
+
+	if needsRehash {
+		// The old hash still verifies, so a failed upgrade shouldn't fail the login
+		if err := rehashPassword(ctx, user, password, updatePasswordIfUnchanged); err != nil {
+			slog.Warn("Rehashing password, keeping the old hash", "user_id", user.ID.String(), "error", err)
+		}
+	}
+
+	return user, nil
+}
+
+// rehashPassword stores a fresh hash of password for user
+func rehashPassword(
+	ctx context.Context,
+	user *User,
+	password string,
+	updatePasswordIfUnchanged func(ctx context.Context, userID ID, oldHash, newHash string) error,
+) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := updatePasswordIfUnchanged(ctx, user.ID, user.Password, hash); err != nil {
+		return err
+	}
+	user.Password = hash
+	return nil
+}
+
+// comparePassword reports whether plaintext matches the stored hash and
+// whether the hash should be replaced with one using the current scheme.
+func comparePassword(stored, plaintext string) (match, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(stored, passwordVersionV1):
+		hash := []byte(strings.TrimPrefix(stored, passwordVersionV1))
+		err := bcrypt.CompareHashAndPassword(hash, []byte(plaintext))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost(hash)
+		if err != nil {
+			return false, false, err
+		}
+		return true, cost < passwordCost, nil
+	case strings.HasPrefix(stored, "$2"), versionedHash.MatchString(stored):
+		// Looks like a hash we don't know how to verify; refuse rather than
+		// falling through to a plaintext comparison.
+		return false, false, errUnknownPasswordHash
+	default:
+		// Legacy plaintext password
+		match := subtle.ConstantTimeCompare([]byte(stored), []byte(plaintext)) == 1
+		return match, match, nil
+	}
+}
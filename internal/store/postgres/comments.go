@@ -0,0 +1,281 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/lib/pq"
+)
+
+// commentColumns are the comments columns read by commentRow.dest
+const commentColumns = `id, post_id, user_id, parent_id, ancestors, depth, content,
+	replies_count, created_at, updated_at`
+
+// commentRow holds a scanned comment until its ancestors are converted
+type commentRow struct {
+	comment   store.Comment
+	ancestors []string
+}
+
+// dest returns the scan destinations matching commentColumns
+func (r *commentRow) dest() []interface{} {
+	c := &r.comment
+	return []interface{}{
+		&c.ID, &c.PostID, nullID{&c.UserID}, &c.ParentID, pq.Array(&r.ancestors), &c.Depth, &c.Content,
+		&c.RepliesCount, &c.CreatedAt, &c.UpdatedAt,
+	}
+}
+
+// value returns the scanned comment
+func (r *commentRow) value() store.Comment {
+	r.comment.Ancestors = make([]store.ID, len(r.ancestors))
+	for i, id := range r.ancestors {
+		r.comment.Ancestors[i] = store.ID(id)
+	}
+	return r.comment
+}
+
+type CommentStore struct {
+	db *sql.DB
+}
+
+// Create adds a comment to a post, or a reply when ParentID is set, and
+// bumps the post's and parent's counters
+func (s *CommentStore) Create(ctx context.Context, comment *store.Comment) error {
+	comment.Ancestors = []store.ID{}
+	comment.Depth = 0
+
+	if comment.ParentID != nil {
+		parent, err := s.GetByID(ctx, *comment.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.PostID != comment.PostID {
+			return store.ErrParentMismatch
+		}
+		comment.Ancestors = append(append(comment.Ancestors, parent.Ancestors...), parent.ID)
+		comment.Depth = parent.Depth + 1
+	}
+
+	comment.ID = store.NewID()
+	comment.RepliesCount = 0
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = time.Now()
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO comments (id, post_id, user_id, parent_id, ancestors, depth, content, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			comment.ID, comment.PostID, comment.UserID, comment.ParentID, pq.Array(idStrings(comment.Ancestors)),
+			comment.Depth, comment.Content, comment.CreatedAt, comment.UpdatedAt)
+		if isForeignKeyViolation(err) {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE posts SET comments_count = comments_count + 1 WHERE id = $1`, comment.PostID)
+		if err != nil {
+			return err
+		}
+
+		if comment.ParentID != nil {
+			_, err := tx.ExecContext(ctx,
+				`UPDATE comments SET replies_count = replies_count + 1 WHERE id = $1`, *comment.ParentID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetByID retrieves a comment by its ID
+func (s *CommentStore) GetByID(ctx context.Context, commentID store.ID) (*store.Comment, error) {
+	var row commentRow
+	err := s.db.QueryRowContext(ctx,
+		`SELECT `+commentColumns+` FROM comments WHERE id = $1`, commentID).Scan(row.dest()...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	comment := row.value()
+	return &comment, nil
+}
+
+// GetByPostID retrieves a page of comments on a post with their authors,
+// newest first. With a nil parentID only top-level comments are returned,
+// otherwise the direct replies to that comment.
+func (s *CommentStore) GetByPostID(ctx context.Context, postID store.ID, parentID *store.ID, page store.Page) ([]store.CommentWithUser, *store.Cursor, error) {
+	args := []interface{}{postID}
+	parent := `parent_id IS NULL`
+	if parentID != nil {
+		args = append(args, *parentID)
+		parent = `parent_id = $2`
+	}
+	after, args := afterCursor(page, "comments", args)
+	args = append(args, page.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments
+		WHERE post_id = $1 AND `+parent+` AND `+after+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	comments := []store.CommentWithUser{}
+	for rows.Next() {
+		var row commentRow
+		if err := rows.Scan(row.dest()...); err != nil {
+			return nil, nil, err
+		}
+		comments = append(comments, store.CommentWithUser{Comment: row.value()})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	comments, next := trimPage(page, comments, func(c store.CommentWithUser) store.Cursor {
+		return store.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+
+	if err := s.attachUsers(ctx, comments); err != nil {
+		return nil, nil, err
+	}
+
+	return comments, next, nil
+}
+
+// attachUsers looks up the authors of comments. Comments by deleted users
+// are left with a nil User.
+func (s *CommentStore) attachUsers(ctx context.Context, comments []store.CommentWithUser) error {
+	userIDs := make([]store.ID, 0, len(comments))
+	for _, c := range comments {
+		if c.UserID != "" {
+			userIDs = append(userIDs, c.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+userColumns+` FROM users u WHERE u.id = ANY($1)`, pq.Array(idStrings(userIDs)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	users := make(map[store.ID]*store.User, len(userIDs))
+	for rows.Next() {
+		var user store.User
+		if err := rows.Scan(userDest(&user)...); err != nil {
+			return err
+		}
+		users[user.ID] = &user
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range comments {
+		comments[i].User = users[comments[i].UserID]
+	}
+	return nil
+}
+
+// Update changes the content of a comment (only by its author)
+func (s *CommentStore) Update(ctx context.Context, commentID, userID store.ID, content string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE comments SET content = $3, updated_at = $4
+		WHERE id = $1 AND user_id = $2`, // Ensure only the author can edit
+		commentID, userID, content, time.Now())
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return s.ownershipError(ctx, commentID)
+	}
+
+	return nil
+}
+
+// Delete deletes a comment and all replies below it. Only the comment's
+// author or the owner of the post may delete it.
+func (s *CommentStore) Delete(ctx context.Context, commentID, userID store.ID) error {
+	comment, err := s.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		var postOwner store.ID
+		err := s.db.QueryRowContext(ctx, `SELECT user_id FROM posts WHERE id = $1`, comment.PostID).Scan(&postOwner)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if postOwner != userID {
+			return store.ErrForbidden
+		}
+	}
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			`DELETE FROM comments WHERE id = $1 OR ancestors @> ARRAY[$1::char(24)]`, commentID)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return store.ErrNotFound
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE posts SET comments_count = comments_count - $2 WHERE id = $1`, comment.PostID, deleted)
+		if err != nil {
+			return err
+		}
+
+		if comment.ParentID != nil {
+			_, err := tx.ExecContext(ctx,
+				`UPDATE comments SET replies_count = replies_count - 1 WHERE id = $1`, *comment.ParentID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ownershipError explains why an author-filtered write matched nothing:
+// store.ErrNotFound if the comment does not exist, store.ErrForbidden otherwise
+func (s *CommentStore) ownershipError(ctx context.Context, commentID store.ID) error {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM comments WHERE id = $1)`, commentID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+	return store.ErrForbidden
+}
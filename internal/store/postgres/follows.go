@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+type FollowStore struct {
+	db *sql.DB
+}
+
+// Follow makes followerID follow followeeID. Following twice is a no-op.
+func (s *FollowStore) Follow(ctx context.Context, followerID, followeeID store.ID) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO follows (id, follower_id, followee_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (follower_id, followee_id) DO NOTHING`,
+		store.NewID(), followerID, followeeID, time.Now())
+	if isForeignKeyViolation(err) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// Unfollow removes the follow edge. Unfollowing a user that is not
+// followed is a no-op.
+func (s *FollowStore) Unfollow(ctx context.Context, followerID, followeeID store.ID) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`, followerID, followeeID)
+	return err
+}
+
+// GetFollowers retrieves a page of users following userID, most recent first
+func (s *FollowStore) GetFollowers(ctx context.Context, userID store.ID, page store.Page) ([]store.FollowWithUser, *store.Cursor, error) {
+	return s.getWithUsers(ctx, "followee_id", "follower_id", userID, page)
+}
+
+// GetFollowing retrieves a page of users userID follows, most recent first
+func (s *FollowStore) GetFollowing(ctx context.Context, userID store.ID, page store.Page) ([]store.FollowWithUser, *store.Cursor, error) {
+	return s.getWithUsers(ctx, "follower_id", "followee_id", userID, page)
+}
+
+// GetCounts returns how many users follow userID and how many userID follows
+func (s *FollowStore) GetCounts(ctx context.Context, userID store.ID) (followers, following int64, err error) {
+	err = s.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT count(*) FROM follows WHERE followee_id = $1),
+			(SELECT count(*) FROM follows WHERE follower_id = $1)`, userID).Scan(&followers, &following)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return followers, following, nil
+}
+
+// getWithUsers pages through follows whose matchColumn is userID and joins
+// the user referenced by userColumn
+func (s *FollowStore) getWithUsers(ctx context.Context, matchColumn, userColumn string, userID store.ID, page store.Page) ([]store.FollowWithUser, *store.Cursor, error) {
+	after, args := afterCursor(page, "f", []interface{}{userID})
+	args = append(args, page.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT f.id, f.follower_id, f.followee_id, f.created_at, `+userColumns+`
+		FROM follows f JOIN users u ON u.id = f.`+userColumn+`
+		WHERE f.`+matchColumn+` = $1 AND `+after+`
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	follows := []store.FollowWithUser{}
+	for rows.Next() {
+		var f store.FollowWithUser
+		dest := []interface{}{&f.ID, &f.FollowerID, &f.FolloweeID, &f.CreatedAt}
+		if err := rows.Scan(append(dest, userDest(&f.User)...)...); err != nil {
+			return nil, nil, err
+		}
+		follows = append(follows, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	follows, next := trimPage(page, follows, func(f store.FollowWithUser) store.Cursor {
+		return store.Cursor{CreatedAt: f.CreatedAt, ID: f.ID}
+	})
+	return follows, next, nil
+}
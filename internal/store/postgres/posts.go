@@ -0,0 +1,284 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/lib/pq"
+)
+
+// postColumns are the posts columns read by postDest, qualified by alias p
+const postColumns = `p.id, p.user_id, p.title, p.content, p.tags, p.comments_count,
+	p.reactions, p.created_at, p.updated_at`
+
+// postDest returns the scan destinations matching postColumns
+func postDest(p *store.Post) []interface{} {
+	return []interface{}{
+		&p.ID, &p.UserID, &p.Title, &p.Content, pq.Array(&p.Tags), &p.CommentsCount,
+		jsonColumn{&p.Reactions}, &p.CreatedAt, &p.UpdatedAt,
+	}
+}
+
+// postCursor returns the pagination position of a post
+func postCursor(p store.Post) store.Cursor {
+	return store.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+type PostStore struct {
+	db *sql.DB
+}
+
+func (s *PostStore) Create(ctx context.Context, post *store.Post) error {
+	post.ID = store.NewID()
+	post.CommentsCount = 0
+	post.CreatedAt = time.Now()
+	post.UpdatedAt = time.Now()
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO posts (id, user_id, title, content, tags, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		post.ID, post.UserID, post.Title, post.Content, pq.Array(post.Tags), post.CreatedAt, post.UpdatedAt)
+	if isForeignKeyViolation(err) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// GetByID retrieves a post by its ID
+func (s *PostStore) GetByID(ctx context.Context, postID store.ID) (*store.Post, error) {
+	var post store.Post
+	err := s.db.QueryRowContext(ctx,
+		`SELECT `+postColumns+` FROM posts p WHERE p.id = $1`, postID).Scan(postDest(&post)...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &post, nil
+}
+
+// GetByUserID retrieves a page of posts by a specific user, newest first
+func (s *PostStore) GetByUserID(ctx context.Context, userID store.ID, page store.Page) ([]store.Post, *store.Cursor, error) {
+	return getPosts(ctx, s.db, userID, page)
+}
+
+// getPosts retrieves a page of posts by userID, newest first
+func getPosts(ctx context.Context, db *sql.DB, userID store.ID, page store.Page) ([]store.Post, *store.Cursor, error) {
+	after, args := afterCursor(page, "p", []interface{}{userID})
+	args = append(args, page.Limit+1)
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+postColumns+`
+		FROM posts p
+		WHERE p.user_id = $1 AND `+after+`
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	posts := []store.Post{}
+	for rows.Next() {
+		var post store.Post
+		if err := rows.Scan(postDest(&post)...); err != nil {
+			return nil, nil, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	posts, next := trimPage(page, posts, postCursor)
+	return posts, next, nil
+}
+
+// GetWithUser retrieves a post with user information
+func (s *PostStore) GetWithUser(ctx context.Context, postID store.ID) (*store.PostWithUser, error) {
+	var post store.PostWithUser
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+postColumns+`, `+userColumns+`
+		FROM posts p JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1`, postID).Scan(append(postDest(&post.Post), userDest(&post.User)...)...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &post, nil
+}
+
+// GetAllWithUsers retrieves a page of posts with user information, newest first
+func (s *PostStore) GetAllWithUsers(ctx context.Context, page store.Page) ([]store.PostWithUser, *store.Cursor, error) {
+	return s.listWithUsers(ctx, `TRUE`, nil, page)
+}
+
+// GetByTag retrieves a page of posts carrying tag with user information, newest first
+func (s *PostStore) GetByTag(ctx context.Context, tag string, page store.Page) ([]store.PostWithUser, *store.Cursor, error) {
+	return s.listWithUsers(ctx, `p.tags @> ARRAY[$1::text]`, []interface{}{tag}, page)
+}
+
+// GetFeed retrieves a page of the home timeline of userID: posts by the
+// user and everyone they follow, newest first, with user information
+func (s *PostStore) GetFeed(ctx context.Context, userID store.ID, page store.Page) ([]store.PostWithUser, *store.Cursor, error) {
+	condition := `p.user_id = $1 OR p.user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1)`
+	return s.listWithUsers(ctx, condition, []interface{}{userID}, page)
+}
+
+// listWithUsers pages through posts matching condition, whose placeholders
+// refer to args, and joins each with its author
+func (s *PostStore) listWithUsers(ctx context.Context, condition string, args []interface{}, page store.Page) ([]store.PostWithUser, *store.Cursor, error) {
+	after, args := afterCursor(page, "p", args)
+	args = append(args, page.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+postColumns+`, `+userColumns+`
+		FROM posts p JOIN users u ON u.id = p.user_id
+		WHERE (`+condition+`) AND `+after+`
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	posts := []store.PostWithUser{}
+	for rows.Next() {
+		var post store.PostWithUser
+		if err := rows.Scan(append(postDest(&post.Post), userDest(&post.User)...)...); err != nil {
+			return nil, nil, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	posts, next := trimPage(page, posts, func(p store.PostWithUser) store.Cursor { return postCursor(p.Post) })
+	return posts, next, nil
+}
+
+// Search runs a full-text search over post titles and content. Text uses
+// web search syntax: "quoted phrases" must match and -words exclude posts.
+func (s *PostStore) Search(ctx context.Context, q store.PostSearch) ([]store.PostSearchResult, error) {
+	args := []interface{}{q.Text}
+	conditions := []string{`p.search @@ tsq`}
+	if len(q.Tags) > 0 {
+		args = append(args, pq.Array(q.Tags))
+		conditions = append(conditions, `p.tags @> $`+strconv.Itoa(len(args)))
+	}
+	if len(q.AuthorIDs) > 0 {
+		args = append(args, pq.Array(idStrings(q.AuthorIDs)))
+		conditions = append(conditions, `p.user_id = ANY($`+strconv.Itoa(len(args))+`)`)
+	}
+	if q.From != nil {
+		args = append(args, *q.From)
+		conditions = append(conditions, `p.created_at >= $`+strconv.Itoa(len(args)))
+	}
+	if q.To != nil {
+		args = append(args, *q.To)
+		conditions = append(conditions, `p.created_at < $`+strconv.Itoa(len(args)))
+	}
+
+	order := `score DESC, p.id DESC`
+	if q.Sort == store.SortRecent {
+		order = `p.created_at DESC, p.id DESC`
+	}
+
+	args = append(args, q.Limit, q.Offset)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+postColumns+`, `+userColumns+`, ts_rank(p.search, tsq) AS score
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		CROSS JOIN websearch_to_tsquery('english', $1) tsq
+		WHERE `+strings.Join(conditions, ` AND `)+`
+		ORDER BY `+order+`
+		LIMIT $`+strconv.Itoa(len(args)-1)+` OFFSET $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []store.PostSearchResult{}
+	for rows.Next() {
+		var result store.PostSearchResult
+		dest := append(postDest(&result.Post), userDest(&result.User)...)
+		if err := rows.Scan(append(dest, &result.Score)...); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Update updates a post (only by the owner)
+func (s *PostStore) Update(ctx context.Context, postID, userID store.ID, changes store.PostUpdate) error {
+	var tags interface{}
+	if changes.Tags != nil {
+		tags = pq.Array(*changes.Tags)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE posts SET
+			title = COALESCE($3, title),
+			content = COALESCE($4, content),
+			tags = COALESCE($5::text[], tags),
+			updated_at = $6
+		WHERE id = $1 AND user_id = $2`, // Ensure only the owner can update
+		postID, userID, changes.Title, changes.Content, tags, time.Now())
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return s.ownershipError(ctx, postID)
+	}
+
+	return nil
+}
+
+// Delete deletes a post (only by the owner). Its comments and reactions go
+// with it through ON DELETE CASCADE.
+func (s *PostStore) Delete(ctx context.Context, postID, userID store.ID) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM posts WHERE id = $1 AND user_id = $2`, postID, userID) // Ensure only the owner can delete
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return s.ownershipError(ctx, postID)
+	}
+
+	return nil
+}
+
+// ownershipError explains why an owner-filtered write matched nothing:
+// store.ErrNotFound if the post does not exist, store.ErrForbidden otherwise
+func (s *PostStore) ownershipError(ctx context.Context, postID store.ID) error {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM posts WHERE id = $1)`, postID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+	return store.ErrForbidden
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/lib/pq"
+)
+
+// ReactionStore keeps reactions and the per-kind counters on posts in
+// step using transactions
+type ReactionStore struct {
+	db *sql.DB
+}
+
+// Add records a reaction and increments the post's counter. It reports
+// false without changing anything if the reaction already exists.
+func (s *ReactionStore) Add(ctx context.Context, postID, userID store.ID, kind string) (bool, error) {
+	var added bool
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO reactions (id, post_id, user_id, kind, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (post_id, user_id, kind) DO NOTHING`,
+			store.NewID(), postID, userID, kind, time.Now())
+		if isForeignKeyViolation(err) {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		inserted, err := result.RowsAffected()
+		if err != nil || inserted == 0 {
+			return err
+		}
+
+		added = true
+		return incReactions(ctx, tx, postID, kind, 1)
+	})
+	return added, err
+}
+
+// Remove deletes a reaction and decrements the post's counter. It reports
+// false without changing anything if there was no such reaction.
+func (s *ReactionStore) Remove(ctx context.Context, postID, userID store.ID, kind string) (bool, error) {
+	var removed bool
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			`DELETE FROM reactions WHERE post_id = $1 AND user_id = $2 AND kind = $3`, postID, userID, kind)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil || deleted == 0 {
+			return err
+		}
+
+		removed = true
+		return incReactions(ctx, tx, postID, kind, -1)
+	})
+	return removed, err
+}
+
+// GetKindsByUser returns the kinds of reaction userID left on each of postIDs
+func (s *ReactionStore) GetKindsByUser(ctx context.Context, postIDs []store.ID, userID store.ID) (map[store.ID][]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT post_id, kind
+		FROM reactions
+		WHERE post_id = ANY($1) AND user_id = $2
+		ORDER BY created_at`, pq.Array(idStrings(postIDs)), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	kinds := make(map[store.ID][]string, len(postIDs))
+	for rows.Next() {
+		var postID store.ID
+		var kind string
+		if err := rows.Scan(&postID, &kind); err != nil {
+			return nil, err
+		}
+		kinds[postID] = append(kinds[postID], kind)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return kinds, nil
+}
+
+// incReactions adjusts the counter of kind on a post by delta
+func incReactions(ctx context.Context, tx *sql.Tx, postID store.ID, kind string, delta int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE posts
+		SET reactions = reactions || jsonb_build_object($2::text, COALESCE((reactions->>$2)::bigint, 0) + $3)
+		WHERE id = $1`, postID, kind, delta)
+	return err
+}
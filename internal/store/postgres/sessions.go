@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/lib/pq"
+)
+
+// sessionColumns are the sessions columns read by sessionDest
+const sessionColumns = `id, user_id, token_hash, previous_token_hashes, user_agent, ip,
+	created_at, last_used_at, expires_at, revoked_at`
+
+// sessionDest returns the scan destinations matching sessionColumns
+func sessionDest(s *store.Session) []interface{} {
+	return []interface{}{
+		&s.ID, &s.UserID, &s.TokenHash, pq.Array(&s.PreviousTokenHashes), &s.UserAgent, &s.IP,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt,
+	}
+}
+
+type SessionStore struct {
+	db *sql.DB
+}
+
+// Create inserts a new session. The ID may be preset by the caller since it
+// is embedded in the refresh token whose hash is stored alongside it.
+func (s *SessionStore) Create(ctx context.Context, session *store.Session) error {
+	if session.ID == "" {
+		session.ID = store.NewID()
+	}
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = session.CreatedAt
+	session.PreviousTokenHashes = []string{}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sessions (id, user_id, token_hash, user_agent, ip, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		session.ID, session.UserID, session.TokenHash, session.UserAgent, session.IP,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt)
+	return err
+}
+
+// GetByID retrieves a session by its ID
+func (s *SessionStore) GetByID(ctx context.Context, sessionID store.ID) (*store.Session, error) {
+	var session store.Session
+	err := s.db.QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, sessionID).Scan(sessionDest(&session)...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &session, nil
+}
+
+// GetActiveByUserID retrieves all live sessions of a user, most recently used first
+func (s *SessionStore) GetActiveByUserID(ctx context.Context, userID store.ID) ([]store.Session, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC`, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []store.Session{}
+	for rows.Next() {
+		var session store.Session
+		if err := rows.Scan(sessionDest(&session)...); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Rotate replaces the session's current refresh token hash with newHash,
+// provided tokenHash is the current one. Presenting an already rotated
+// token revokes the session and returns store.ErrRefreshTokenReused.
+func (s *SessionStore) Rotate(ctx context.Context, sessionID store.ID, tokenHash, newHash string, expiresAt time.Time) (*store.Session, error) {
+	now := time.Now()
+
+	var session store.Session
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE sessions SET
+			token_hash = $3,
+			last_used_at = $4,
+			expires_at = $5,
+			previous_token_hashes = (previous_token_hashes || $2::text)[
+				greatest(cardinality(previous_token_hashes) + 2 - $6, 1):]
+		WHERE id = $1 AND token_hash = $2 AND revoked_at IS NULL AND expires_at > $4
+		RETURNING `+sessionColumns,
+		sessionID, tokenHash, newHash, now, expiresAt, store.MaxPreviousTokenHashes).Scan(sessionDest(&session)...)
+	if err == nil {
+		return &session, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// Work out why the token was rejected
+	existing, err := s.GetByID(ctx, sessionID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, store.ErrSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.RevokedAt == nil && existing.TokenHash != tokenHash && existing.HasTokenHash(tokenHash) {
+		// A rotated token came back: either the client or an attacker holds
+		// a stolen copy, so kill the whole family.
+		if err := s.Revoke(ctx, existing.ID, existing.UserID); err != nil {
+			return nil, err
+		}
+		return nil, store.ErrRefreshTokenReused
+	}
+
+	return nil, store.ErrSessionInvalid
+}
+
+// Revoke revokes a session (only by its owner)
+func (s *SessionStore) Revoke(ctx context.Context, sessionID, userID store.ID) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, // Ensure only the owner can revoke
+		sessionID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return expectRows(result)
+}
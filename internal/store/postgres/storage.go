@@ -0,0 +1,170 @@
+// Package postgres implements store.Storage on PostgreSQL. The schema is
+// created by the migrations in internal/db.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+	"github.com/lib/pq"
+)
+
+// uniqueFields maps unique constraints to the field reported in
+// store.DuplicateError
+var uniqueFields = map[string]string{
+	"users_email_unique":    "email",
+	"users_username_unique": "username",
+}
+
+// NewStorage returns PostgreSQL-backed stores using db. Home feeds are
+// always built at read time; fan-out timelines are only available on Mongo.
+func NewStorage(db *sql.DB) store.Storage {
+	return store.WrapErrors(store.Storage{
+		Users:     &UserStore{db: db},
+		Posts:     &PostStore{db: db},
+		Sessions:  &SessionStore{db: db},
+		Comments:  &CommentStore{db: db},
+		Reactions: &ReactionStore{db: db},
+		Tags:      &TagStore{db: db},
+		Follows:   &FollowStore{db: db},
+	}, wrapError)
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// withTx runs fn in a transaction, committing if it returns nil
+func withTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// wrapError types errors leaving the stores so callers don't need to know
+// the driver: connection failures and timeouts wrap store.ErrUnavailable,
+// and errors a method didn't map itself go through mapError
+func wrapError(err error) error {
+	var pqErr *pq.Error
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, driver.ErrBadConn), errors.As(err, &netErr),
+		// Class 08 is connection exceptions, 57P01-57P03 the server shutting down
+		errors.As(err, &pqErr) && (pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"):
+		return fmt.Errorf("%w: %w", store.ErrUnavailable, err)
+	default:
+		return mapError(err)
+	}
+}
+
+// mapError converts driver errors into their store equivalents: missing
+// rows into store.ErrNotFound and unique violations into
+// *store.DuplicateError. Other errors are returned unchanged.
+func mapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		field, ok := uniqueFields[pqErr.Constraint]
+		if !ok {
+			field = "unknown"
+		}
+		return &store.DuplicateError{Field: field}
+	}
+
+	return err
+}
+
+// isForeignKeyViolation reports whether err is caused by a reference to a
+// row that does not exist
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// afterCursor returns the condition selecting rows of table strictly after
+// the page cursor, appending its arguments to args. Rows are ordered by
+// created_at and id, both descending.
+func afterCursor(page store.Page, table string, args []interface{}) (string, []interface{}) {
+	if page.After == nil {
+		return "TRUE", args
+	}
+	args = append(args, page.After.CreatedAt, page.After.ID)
+	return fmt.Sprintf("(%[1]s.created_at, %[1]s.id) < ($%[2]d, $%[3]d)", table, len(args)-1, len(args)), args
+}
+
+// trimPage cuts items down to the page size and returns the cursor of the
+// next page, or nil when this is the last one. Queries fetch one item more
+// than the page size so the next page can be detected.
+func trimPage[T any](page store.Page, items []T, cursorOf func(T) store.Cursor) ([]T, *store.Cursor) {
+	if int64(len(items)) <= page.Limit {
+		return items, nil
+	}
+	items = items[:page.Limit]
+	next := cursorOf(items[len(items)-1])
+	return items, &next
+}
+
+// idStrings converts ids for use with pq.Array
+func idStrings(ids []store.ID) []string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = string(id)
+	}
+	return s
+}
+
+// jsonColumn scans a JSON or JSONB column into v
+type jsonColumn struct {
+	v interface{}
+}
+
+func (c jsonColumn) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(src, c.v)
+	case string:
+		return json.Unmarshal([]byte(src), c.v)
+	default:
+		return fmt.Errorf("cannot scan %T into a JSON column", src)
+	}
+}
+
+// nullID scans a nullable ID column, using the empty ID for NULL
+type nullID struct {
+	id *store.ID
+}
+
+func (n nullID) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*n.id = ""
+	case []byte:
+		*n.id = store.ID(src)
+	case string:
+		*n.id = store.ID(src)
+	default:
+		return fmt.Errorf("cannot scan %T into an ID", src)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+// TagStore answers tag queries over the posts table
+type TagStore struct {
+	db *sql.DB
+}
+
+// GetTrending returns the limit most used tags on posts created within
+// window, most used first
+func (s *TagStore) GetTrending(ctx context.Context, window time.Duration, limit int64) ([]store.TagCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT tag, count(*) AS count
+		FROM posts, unnest(tags) AS tag
+		WHERE created_at >= $1
+		GROUP BY tag
+		ORDER BY count DESC, tag
+		LIMIT $2`, time.Now().Add(-window), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []store.TagCount{}
+	for rows.Next() {
+		var tag store.TagCount
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Nutan-Kum12/Gopherso/internal/store"
+)
+
+// userColumns are the users columns read by userDest, qualified by alias u
+const userColumns = `u.id, u.username, u.email, u.password, u.bio, u.pending_email,
+	u.email_change_token_hash, u.email_change_expires_at, u.created_at, u.updated_at`
+
+// userDest returns the scan destinations matching userColumns
+func userDest(u *store.User) []interface{} {
+	return []interface{}{
+		&u.ID, &u.Username, &u.Email, &u.Password, &u.Bio, &u.PendingEmail,
+		&u.EmailChangeTokenHash, &u.EmailChangeExpiresAt, &u.CreatedAt, &u.UpdatedAt,
+	}
+}
+
+type UserStore struct {
+	db *sql.DB
+}
+
+func (s *UserStore) Create(ctx context.Context, user *store.User) error {
+	user.ID = store.NewID()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (id, username, email, password, bio, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		user.ID, user.Username, user.Email, user.Password, user.Bio, user.CreatedAt, user.UpdatedAt)
+	return mapError(err)
+}
+
+// GetByID retrieves a user by their ID
+func (s *UserStore) GetByID(ctx context.Context, userID store.ID) (*store.User, error) {
+	return s.getOne(ctx, `u.id = $1`, userID)
+}
+
+// GetByEmail retrieves a user by email, ignoring case
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
+	return s.getOne(ctx, `lower(u.email) = lower($1)`, email)
+}
+
+// getOne retrieves the user matching condition
+func (s *UserStore) getOne(ctx context.Context, condition string, args ...interface{}) (*store.User, error) {
+	var user store.User
+	err := s.db.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM users u WHERE `+condition, args...).Scan(userDest(&user)...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &user, nil
+}
+
+// GetWithPosts retrieves a user with a page of their posts, newest first
+func (s *UserStore) GetWithPosts(ctx context.Context, userID store.ID, page store.Page) (*store.UserWithPosts, *store.Cursor, error) {
+	// First get the user
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Then get a page of posts by this user
+	posts, next, err := getPosts(ctx, s.db, userID, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &store.UserWithPosts{
+		User:  *user,
+		Posts: posts,
+	}, next, nil
+}
+
+// GetPostsCount returns the number of posts for a user
+func (s *UserStore) GetPostsCount(ctx context.Context, userID store.ID) (int64, error) {
+	var count int64
+	err := s.db.QueryRowContext(ctx, `SELECT count(*) FROM posts WHERE user_id = $1`, userID).Scan(&count)
+	return count, err
+}
+
+// VerifyCredentials returns the user matching email if password is correct
+func (s *UserStore) VerifyCredentials(ctx context.Context, email, password string) (*store.User, error) {
+	return store.CheckCredentials(ctx, email, password, s.GetByEmail, s.updatePasswordIfUnchanged)
+}
+
+// updatePasswordIfUnchanged replaces the user's password hash if it is still oldHash
+func (s *UserStore) updatePasswordIfUnchanged(ctx context.Context, userID store.ID, oldHash, newHash string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET password = $1 WHERE id = $2 AND password = $3`,
+		newHash, userID, oldHash)
+	return err
+}
+
+// Update updates a user's profile
+func (s *UserStore) Update(ctx context.Context, userID store.ID, changes store.UserUpdate) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET
+			username = COALESCE($2, username),
+			bio = COALESCE($3, bio),
+			updated_at = $4
+		WHERE id = $1`,
+		userID, changes.Username, changes.Bio, time.Now())
+	if err != nil {
+		return mapError(err)
+	}
+
+	return expectRows(result)
+}
+
+// RequestEmailChange records newEmail as pending until the token whose hash
+// is tokenHash is confirmed. Any earlier pending change is replaced.
+func (s *UserStore) RequestEmailChange(ctx context.Context, userID store.ID, newEmail, tokenHash string, expiresAt time.Time) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET
+			pending_email = $2,
+			email_change_token_hash = $3,
+			email_change_expires_at = $4
+		WHERE id = $1`,
+		userID, newEmail, tokenHash, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return expectRows(result)
+}
+
+// ConfirmEmailChange swaps in the pending email of the user holding
+// tokenHash. The token can only be used once.
+func (s *UserStore) ConfirmEmailChange(ctx context.Context, tokenHash string) (*store.User, error) {
+	var user store.User
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE users u SET
+			email = u.pending_email,
+			pending_email = '',
+			email_change_token_hash = '',
+			email_change_expires_at = NULL,
+			updated_at = $2
+		WHERE u.email_change_token_hash = $1 AND u.email_change_expires_at > $2
+		RETURNING `+userColumns,
+		tokenHash, time.Now()).Scan(userDest(&user)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrInvalidEmailChangeToken
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &user, nil
+}
+
+// Delete deletes a user in one transaction. Their posts, sessions, follows
+// and reactions cascade via ON DELETE CASCADE once the counters on other
+// users' posts are decremented.
+func (s *UserStore) Delete(ctx context.Context, userID store.ID) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE posts p SET reactions = p.reactions || (
+				SELECT jsonb_object_agg(r.kind, COALESCE((p.reactions->>r.kind)::bigint, 0) - 1)
+				FROM reactions r
+				WHERE r.post_id = p.id AND r.user_id = $1
+			)
+			WHERE p.user_id <> $1 AND p.id IN (SELECT post_id FROM reactions WHERE user_id = $1)`, userID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE comments SET user_id = NULL, content = '' WHERE user_id = $1`, userID)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID)
+		if err != nil {
+			return err
+		}
+
+		return expectRows(result)
+	})
+}
+
+// expectRows returns store.ErrNotFound if result affected no rows
+func expectRows(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -2,22 +2,44 @@ package store
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrForbidden is returned when a user modifies a post they do not own
+var ErrForbidden = errors.New("forbidden")
+
+// PostUpdate holds the post fields that can be changed after creation.
+// Nil fields are left untouched.
+type PostUpdate struct {
+	Title   *string
+	Content *string
+	Tags    *[]string
+}
+
 type Post struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Content   string             `json:"content" bson:"content"`
-	Title     string             `json:"title" bson:"title"`
-	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
-	Tags      []string           `json:"tags" bson:"tags"`
-	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	ID      ID       `json:"id" bson:"_id,omitempty"`
+	Content string   `json:"content" bson:"content"`
+	Title   string   `json:"title" bson:"title"`
+	UserID  ID       `json:"user_id" bson:"user_id"`
+	Tags    []string `json:"tags" bson:"tags"`
+
+	// Denormalized counters
+	CommentsCount int64            `json:"comments_count" bson:"comments_count"`
+	Reactions     map[string]int64 `json:"reactions" bson:"reactions,omitempty"` // Count per reaction kind
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// cursor returns the pagination position of the post
+func (p Post) cursor() Cursor {
+	return Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
 }
 
 // PostWithUser represents a post with user information
@@ -27,53 +49,64 @@ type PostWithUser struct {
 }
 
 type PostStore struct {
-	collection      *mongo.Collection
-	usersCollection *mongo.Collection
+	collection          *mongo.Collection
+	usersCollection     *mongo.Collection
+	followsCollection   *mongo.Collection
+	commentsCollection  *mongo.Collection
+	reactionsCollection *mongo.Collection
+	fanout              *TimelineFanout // nil unless fan-out-on-write is enabled
 }
 
 func (s *PostStore) Create(ctx context.Context, post *Post) error {
-	post.ID = primitive.NewObjectID()
+	post.ID = NewID()
+	post.CommentsCount = 0
 	post.CreatedAt = time.Now()
 	post.UpdatedAt = time.Now()
 
-	result, err := s.collection.InsertOne(ctx, post)
+	_, err := s.collection.InsertOne(ctx, post)
 	if err != nil {
 		return err
 	}
 
-	post.ID = result.InsertedID.(primitive.ObjectID)
+	if s.fanout != nil && !s.fanout.Enqueue(*post) {
+		slog.Warn("Timeline fan-out queue full or closed, post will only be pulled", "post_id", post.ID.String())
+		if err := s.fanout.recordPull(ctx, *post); err != nil {
+			slog.Error("Recording post to pull", "post_id", post.ID.String(), "error", err)
+		}
+	}
 	return nil
 }
 
 // GetByID retrieves a post by its ID
-func (s *PostStore) GetByID(ctx context.Context, postID primitive.ObjectID) (*Post, error) {
+func (s *PostStore) GetByID(ctx context.Context, postID ID) (*Post, error) {
 	var post Post
 	err := s.collection.FindOne(ctx, bson.M{"_id": postID}).Decode(&post)
 	if err != nil {
-		return nil, err
+		return nil, mapNotFound(err)
 	}
 	return &post, nil
 }
 
-// GetByUserID retrieves all posts by a specific user
-func (s *PostStore) GetByUserID(ctx context.Context, userID primitive.ObjectID) ([]Post, error) {
-	cursor, err := s.collection.Find(ctx, bson.M{"user_id": userID},
-		options.Find().SetSort(bson.D{{"created_at", -1}}))
+// GetByUserID retrieves a page of posts by a specific user, newest first
+func (s *PostStore) GetByUserID(ctx context.Context, userID ID, page Page) ([]Post, *Cursor, error) {
+	cursor, err := s.collection.Find(ctx, page.filter(bson.M{"user_id": userID}),
+		options.Find().SetSort(pageSort).SetLimit(page.fetchLimit()))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var posts []Post
 	if err = cursor.All(ctx, &posts); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return posts, nil
+	posts, next := trimPage(page, posts, Post.cursor)
+	return posts, next, nil
 }
 
 // GetWithUser retrieves a post with user information
-func (s *PostStore) GetWithUser(ctx context.Context, postID primitive.ObjectID) (*PostWithUser, error) {
+func (s *PostStore) GetWithUser(ctx context.Context, postID ID) (*PostWithUser, error) {
 	// First get the post
 	post, err := s.GetByID(ctx, postID)
 	if err != nil {
@@ -84,7 +117,7 @@ func (s *PostStore) GetWithUser(ctx context.Context, postID primitive.ObjectID)
 	var user User
 	err = s.usersCollection.FindOne(ctx, bson.M{"_id": post.UserID}).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, mapNotFound(err)
 	}
 
 	return &PostWithUser{
@@ -93,10 +126,91 @@ func (s *PostStore) GetWithUser(ctx context.Context, postID primitive.ObjectID)
 	}, nil
 }
 
-// GetAllWithUsers retrieves all posts with user information
-func (s *PostStore) GetAllWithUsers(ctx context.Context, limit int64) ([]PostWithUser, error) {
-	// MongoDB aggregation pipeline to join posts with users
+// GetAllWithUsers retrieves a page of posts with user information, newest first
+func (s *PostStore) GetAllWithUsers(ctx context.Context, page Page) ([]PostWithUser, *Cursor, error) {
+	return s.aggregateWithUsers(ctx, bson.M{}, page)
+}
+
+// GetByTag retrieves a page of posts carrying tag with user information, newest first
+func (s *PostStore) GetByTag(ctx context.Context, tag string, page Page) ([]PostWithUser, *Cursor, error) {
+	return s.aggregateWithUsers(ctx, bson.M{"tags": tag}, page)
+}
+
+// GetFeed retrieves a page of the home timeline of userID: posts by the
+// user and everyone they follow, newest first, with user information
+func (s *PostStore) GetFeed(ctx context.Context, userID ID, page Page) ([]PostWithUser, *Cursor, error) {
+	ids, err := s.followsCollection.Distinct(ctx, "followee_id", bson.M{"follower_id": userID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	followees := idsFromValues(ids)
+	pullAll := bson.M{"user_id": bson.M{"$in": append(followees, userID)}}
+
+	if s.fanout == nil {
+		return s.aggregateWithUsers(ctx, pullAll, page)
+	}
+
+	// Hybrid: pushed posts, pulled posts and the user's own ones as far back
+	// as the timeline reaches, and every followee's posts beyond that
+	entries, err := s.fanout.timelineEntries(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(entries) == 0 {
+		return s.aggregateWithUsers(ctx, pullAll, page)
+	}
+	oldest := entries[len(entries)-1].CreatedAt
+	if page.After != nil && page.After.CreatedAt.Before(oldest) {
+		return s.aggregateWithUsers(ctx, pullAll, page)
+	}
+
+	pulls, err := s.fanout.pulledAuthors(ctx, followees)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Wrapped in $and since the page filter adds its own $or
+	cached := bson.M{"$and": bson.A{cachedFeedFilter(userID, entries, pulls)}}
+	posts, next, err := s.aggregateWithUsers(ctx, cached, page)
+	if err != nil || next != nil {
+		return posts, next, err
+	}
+
+	// The page runs past the timeline, so fill it up with older posts
+	older := bson.M{"$and": bson.A{pullAll, bson.M{"created_at": bson.M{"$lt": oldest}}}}
+	remaining := page.Limit - int64(len(posts))
+	if remaining == 0 {
+		n, err := s.collection.CountDocuments(ctx, older, options.Count().SetLimit(1))
+		if err != nil || n == 0 {
+			return posts, nil, err
+		}
+		next := posts[len(posts)-1].cursor()
+		return posts, &next, nil
+	}
+
+	olderPosts, next, err := s.aggregateWithUsers(ctx, older, Page{Limit: remaining})
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(posts, olderPosts...), next, nil
+}
+
+// aggregateWithUsers pages through posts matching filter and joins each
+// with its author
+func (s *PostStore) aggregateWithUsers(ctx context.Context, filter bson.M, page Page) ([]PostWithUser, *Cursor, error) {
+	// MongoDB aggregation pipeline to join posts with users. Paginate
+	// before the join so only one page of posts is looked up.
 	pipeline := []bson.M{
+		{
+			"$match": page.filter(filter),
+		},
+		{
+			"$sort": pageSort,
+		},
+		{
+			"$limit": page.fetchLimit(),
+		},
 		{
 			"$lookup": bson.M{
 				"from":         "users",
@@ -108,32 +222,35 @@ func (s *PostStore) GetAllWithUsers(ctx context.Context, limit int64) ([]PostWit
 		{
 			"$unwind": "$user",
 		},
-		{
-			"$sort": bson.D{{"created_at", -1}},
-		},
-	}
-
-	if limit > 0 {
-		pipeline = append(pipeline, bson.M{"$limit": limit})
 	}
 
 	cursor, err := s.collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var posts []PostWithUser
 	if err = cursor.All(ctx, &posts); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return posts, nil
+	posts, next := trimPage(page, posts, PostWithUser.cursor)
+	return posts, next, nil
 }
 
 // Update updates a post (only by the owner)
-func (s *PostStore) Update(ctx context.Context, postID, userID primitive.ObjectID, updateData bson.M) error {
-	updateData["updated_at"] = time.Now()
+func (s *PostStore) Update(ctx context.Context, postID, userID ID, changes PostUpdate) error {
+	updateData := bson.M{"updated_at": time.Now()}
+	if changes.Title != nil {
+		updateData["title"] = *changes.Title
+	}
+	if changes.Content != nil {
+		updateData["content"] = *changes.Content
+	}
+	if changes.Tags != nil {
+		updateData["tags"] = *changes.Tags
+	}
 
 	filter := bson.M{
 		"_id":     postID,
@@ -148,14 +265,14 @@ func (s *PostStore) Update(ctx context.Context, postID, userID primitive.ObjectI
 	}
 
 	if result.MatchedCount == 0 {
-		return mongo.ErrNoDocuments
+		return s.ownershipError(ctx, postID)
 	}
 
 	return nil
 }
 
-// Delete deletes a post (only by the owner)
-func (s *PostStore) Delete(ctx context.Context, postID, userID primitive.ObjectID) error {
+// Delete deletes a post with its comments and reactions (only by the owner)
+func (s *PostStore) Delete(ctx context.Context, postID, userID ID) error {
 	filter := bson.M{
 		"_id":     postID,
 		"user_id": userID, // Ensure only the owner can delete
@@ -167,8 +284,29 @@ func (s *PostStore) Delete(ctx context.Context, postID, userID primitive.ObjectI
 	}
 
 	if result.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
+		return s.ownershipError(ctx, postID)
+	}
+
+	// Remove the post's comments and reactions
+	if _, err := s.commentsCollection.DeleteMany(ctx, bson.M{"post_id": postID}); err != nil {
+		return err
+	}
+	if _, err := s.reactionsCollection.DeleteMany(ctx, bson.M{"post_id": postID}); err != nil {
+		return err
 	}
 
 	return nil
 }
+
+// ownershipError explains why an owner-filtered write matched nothing:
+// ErrNotFound if the post does not exist, ErrForbidden otherwise
+func (s *PostStore) ownershipError(ctx context.Context, postID ID) error {
+	count, err := s.collection.CountDocuments(ctx, bson.M{"_id": postID}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrForbidden
+}
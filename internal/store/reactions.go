@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReactionKinds are the reactions a user can leave on a post
+var ReactionKinds = map[string]bool{
+	"like":  true,
+	"love":  true,
+	"laugh": true,
+	"wow":   true,
+	"sad":   true,
+	"angry": true,
+}
+
+// Reaction is one user's reaction of one kind to a post. A user may leave
+// several kinds on the same post but each kind only once.
+type Reaction struct {
+	ID        ID        `json:"id" bson:"_id,omitempty"`
+	PostID    ID        `json:"post_id" bson:"post_id"`
+	UserID    ID        `json:"user_id" bson:"user_id"`
+	Kind      string    `json:"kind" bson:"kind"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// ReactionStore keeps reactions and the per-kind counters on posts in
+// step using multi-document transactions, so MongoDB must run as a
+// replica set.
+type ReactionStore struct {
+	client          *mongo.Client
+	collection      *mongo.Collection
+	postsCollection *mongo.Collection
+}
+
+// Add records a reaction and increments the post's counter. It reports
+// false without changing anything if the reaction already exists.
+func (s *ReactionStore) Add(ctx context.Context, postID, userID ID, kind string) (bool, error) {
+	key := bson.M{"post_id": postID, "user_id": userID, "kind": kind}
+
+	added, err := s.withTransaction(ctx, func(sc mongo.SessionContext) (bool, error) {
+		count, err := s.collection.CountDocuments(sc, key, options.Count().SetLimit(1))
+		if err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return false, nil
+		}
+
+		reaction := Reaction{
+			ID:        NewID(),
+			PostID:    postID,
+			UserID:    userID,
+			Kind:      kind,
+			CreatedAt: time.Now(),
+		}
+		if _, err := s.collection.InsertOne(sc, reaction); err != nil {
+			return false, err
+		}
+
+		result, err := s.postsCollection.UpdateOne(sc, bson.M{"_id": postID},
+			bson.M{"$inc": bson.M{"reactions." + kind: 1}})
+		if err != nil {
+			return false, err
+		}
+		if result.MatchedCount == 0 {
+			return false, ErrNotFound
+		}
+
+		return true, nil
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent identical request won
+		return false, nil
+	}
+	return added, err
+}
+
+// Remove deletes a reaction and decrements the post's counter. It reports
+// false without changing anything if there was no such reaction.
+func (s *ReactionStore) Remove(ctx context.Context, postID, userID ID, kind string) (bool, error) {
+	key := bson.M{"post_id": postID, "user_id": userID, "kind": kind}
+
+	return s.withTransaction(ctx, func(sc mongo.SessionContext) (bool, error) {
+		result, err := s.collection.DeleteOne(sc, key)
+		if err != nil {
+			return false, err
+		}
+		if result.DeletedCount == 0 {
+			return false, nil
+		}
+
+		_, err = s.postsCollection.UpdateOne(sc, bson.M{"_id": postID},
+			bson.M{"$inc": bson.M{"reactions." + kind: -1}})
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+}
+
+// GetKindsByUser returns the kinds of reaction userID left on each of postIDs
+func (s *ReactionStore) GetKindsByUser(ctx context.Context, postIDs []ID, userID ID) (map[ID][]string, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{
+		"post_id": bson.M{"$in": postIDs},
+		"user_id": userID,
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reactions []Reaction
+	if err = cursor.All(ctx, &reactions); err != nil {
+		return nil, err
+	}
+
+	kinds := make(map[ID][]string, len(postIDs))
+	for _, r := range reactions {
+		kinds[r.PostID] = append(kinds[r.PostID], r.Kind)
+	}
+	return kinds, nil
+}
+
+// withTransaction runs fn in a transaction, retrying on transient errors
+func (s *ReactionStore) withTransaction(ctx context.Context, fn func(mongo.SessionContext) (bool, error)) (bool, error) {
+	session, err := s.client.StartSession()
+	if err != nil {
+		return false, err
+	}
+	defer session.EndSession(ctx)
+
+	result, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return fn(sc)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	changed, ok := result.(bool)
+	if !ok {
+		return false, errors.New("unexpected transaction result")
+	}
+	return changed, nil
+}
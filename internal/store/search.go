@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Search sort orders
+const (
+	SortRelevance = "relevance"
+	SortRecent    = "recent"
+)
+
+// PostSearch describes a full-text post search. Text uses web search
+// syntax: "quoted phrases" must match and -words exclude posts.
+type PostSearch struct {
+	Text      string
+	Tags      []string   // Posts must carry all of these
+	AuthorIDs []ID       // Posts must be by one of these, if any
+	From      *time.Time // Created at or after
+	To        *time.Time // Created before
+	Sort      string     // SortRelevance or SortRecent
+	Limit     int64
+	Offset    int64
+}
+
+// PostSearchResult is a matching post with its author and text score
+type PostSearchResult struct {
+	PostWithUser `bson:",inline"`
+	Score        float64 `json:"score" bson:"score"`
+}
+
+// Search runs a full-text search over post titles and content
+func (s *PostStore) Search(ctx context.Context, q PostSearch) ([]PostSearchResult, error) {
+	filter := bson.M{"$text": bson.M{"$search": q.Text}}
+	if len(q.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": q.Tags}
+	}
+	if len(q.AuthorIDs) > 0 {
+		filter["user_id"] = bson.M{"$in": q.AuthorIDs}
+	}
+	if q.From != nil || q.To != nil {
+		createdAt := bson.M{}
+		if q.From != nil {
+			createdAt["$gte"] = *q.From
+		}
+		if q.To != nil {
+			createdAt["$lt"] = *q.To
+		}
+		filter["created_at"] = createdAt
+	}
+
+	sort := bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: -1}}
+	if q.Sort == SortRecent {
+		sort = pageSort
+	}
+
+	pipeline := []bson.M{
+		{
+			"$match": filter,
+		},
+		{
+			"$addFields": bson.M{"score": bson.M{"$meta": "textScore"}},
+		},
+		{
+			"$sort": sort,
+		},
+		{
+			"$skip": q.Offset,
+		},
+		{
+			"$limit": q.Limit,
+		},
+		{
+			"$lookup": bson.M{
+				"from":         "users",
+				"localField":   "user_id",
+				"foreignField": "_id",
+				"as":           "user",
+			},
+		},
+		{
+			"$unwind": "$user",
+		},
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []PostSearchResult
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrSessionInvalid is returned when a refresh token does not belong to
+	// a live session
+	ErrSessionInvalid = errors.New("session is invalid, expired or revoked")
+	// ErrRefreshTokenReused is returned when an already rotated refresh
+	// token is presented again. The session is revoked when this happens.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// MaxPreviousTokenHashes bounds how many rotated refresh tokens are
+// remembered per session for reuse detection
+const MaxPreviousTokenHashes = 20
+
+// Session is a refresh token family, typically one per logged-in device.
+// Every refresh rotates TokenHash; presenting a previous token revokes the
+// whole session.
+type Session struct {
+	ID                  ID         `json:"id" bson:"_id,omitempty"`
+	UserID              ID         `json:"user_id" bson:"user_id"`
+	TokenHash           string     `json:"-" bson:"token_hash"`
+	PreviousTokenHashes []string   `json:"-" bson:"previous_token_hashes"`
+	UserAgent           string     `json:"user_agent" bson:"user_agent"`
+	IP                  string     `json:"ip" bson:"ip"`
+	CreatedAt           time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt          time.Time  `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt           time.Time  `json:"expires_at" bson:"expires_at"`
+	RevokedAt           *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// IsActive reports whether the session can still be used at now: it is
+// neither revoked nor expired
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// HasTokenHash reports whether hash is the current or a previously rotated
+// token of this session
+func (s *Session) HasTokenHash(hash string) bool {
+	if s.TokenHash == hash {
+		return true
+	}
+	for _, h := range s.PreviousTokenHashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+type SessionStore struct {
+	collection *mongo.Collection
+}
+
+// Create inserts a new session. The ID may be preset by the caller since it
+// is embedded in the refresh token whose hash is stored alongside it.
+func (s *SessionStore) Create(ctx context.Context, session *Session) error {
+	if session.ID == "" {
+		session.ID = NewID()
+	}
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = session.CreatedAt
+	session.PreviousTokenHashes = []string{}
+
+	_, err := s.collection.InsertOne(ctx, session)
+	return err
+}
+
+// GetByID retrieves a session by its ID
+func (s *SessionStore) GetByID(ctx context.Context, sessionID ID) (*Session, error) {
+	var session Session
+	err := s.collection.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&session)
+	if err != nil {
+		return nil, mapNotFound(err)
+	}
+	return &session, nil
+}
+
+// GetActiveByUserID retrieves all live sessions of a user, most recently used first
+func (s *SessionStore) GetActiveByUserID(ctx context.Context, userID ID) ([]Session, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	cursor, err := s.collection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []Session
+	if err = cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Rotate replaces the session's current refresh token hash with newHash,
+// provided tokenHash is the current one. Presenting an already rotated
+// token revokes the session and returns ErrRefreshTokenReused.
+func (s *SessionStore) Rotate(ctx context.Context, sessionID ID, tokenHash, newHash string, expiresAt time.Time) (*Session, error) {
+	now := time.Now()
+
+	filter := bson.M{
+		"_id":        sessionID,
+		"token_hash": tokenHash,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"token_hash":   newHash,
+			"last_used_at": now,
+			"expires_at":   expiresAt,
+		},
+		"$push": bson.M{
+			"previous_token_hashes": bson.M{
+				"$each":  []string{tokenHash},
+				"$slice": -MaxPreviousTokenHashes,
+			},
+		},
+	}
+
+	var session Session
+	err := s.collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&session)
+	if err == nil {
+		return &session, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// Work out why the token was rejected
+	existing, err := s.GetByID(ctx, sessionID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.RevokedAt == nil && existing.TokenHash != tokenHash && existing.HasTokenHash(tokenHash) {
+		// A rotated token came back: either the client or an attacker holds
+		// a stolen copy, so kill the whole family.
+		if err := s.Revoke(ctx, existing.ID, existing.UserID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return nil, ErrSessionInvalid
+}
+
+// Revoke revokes a session (only by its owner)
+func (s *SessionStore) Revoke(ctx context.Context, sessionID, userID ID) error {
+	filter := bson.M{
+		"_id":        sessionID,
+		"user_id":    userID, // Ensure only the owner can revoke
+		"revoked_at": nil,
+	}
+
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
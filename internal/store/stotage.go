@@ -2,44 +2,119 @@ package store
 
 import (
 	"context"
+	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Storage struct {
 	Posts interface {
 		Create(context.Context, *Post) error
-		GetByID(context.Context, primitive.ObjectID) (*Post, error)
-		GetByUserID(context.Context, primitive.ObjectID) ([]Post, error)
-		GetWithUser(context.Context, primitive.ObjectID) (*PostWithUser, error)
-		GetAllWithUsers(context.Context, int64) ([]PostWithUser, error)
-		Update(context.Context, primitive.ObjectID, primitive.ObjectID, bson.M) error
-		Delete(context.Context, primitive.ObjectID, primitive.ObjectID) error
+		GetByID(context.Context, ID) (*Post, error)
+		GetByUserID(context.Context, ID, Page) ([]Post, *Cursor, error)
+		GetWithUser(context.Context, ID) (*PostWithUser, error)
+		GetAllWithUsers(context.Context, Page) ([]PostWithUser, *Cursor, error)
+		GetFeed(context.Context, ID, Page) ([]PostWithUser, *Cursor, error)
+		GetByTag(context.Context, string, Page) ([]PostWithUser, *Cursor, error)
+		Search(context.Context, PostSearch) ([]PostSearchResult, error)
+		Update(context.Context, ID, ID, PostUpdate) error
+		Delete(context.Context, ID, ID) error
 	}
 	Users interface {
 		Create(context.Context, *User) error
-		GetByID(context.Context, primitive.ObjectID) (*User, error)
+		GetByID(context.Context, ID) (*User, error)
 		GetByEmail(context.Context, string) (*User, error)
-		GetWithPosts(context.Context, primitive.ObjectID) (*UserWithPosts, error)
-		GetPostsCount(context.Context, primitive.ObjectID) (int64, error)
+		GetWithPosts(context.Context, ID, Page) (*UserWithPosts, *Cursor, error)
+		GetPostsCount(context.Context, ID) (int64, error)
+		VerifyCredentials(ctx context.Context, email, password string) (*User, error)
+		Update(context.Context, ID, UserUpdate) error
+		RequestEmailChange(ctx context.Context, userID ID, newEmail, tokenHash string, expiresAt time.Time) error
+		ConfirmEmailChange(ctx context.Context, tokenHash string) (*User, error)
+		// Delete deletes a user together with their posts, sessions, follows
+		// and reactions, decrementing the counters their reactions added to.
+		// Their comments on other users' posts are blanked rather than
+		// deleted so the threads and counters they belong to stay intact.
+		Delete(context.Context, ID) error
+	}
+	Sessions interface {
+		Create(context.Context, *Session) error
+		GetByID(context.Context, ID) (*Session, error)
+		GetActiveByUserID(context.Context, ID) ([]Session, error)
+		Rotate(ctx context.Context, sessionID ID, tokenHash, newHash string, expiresAt time.Time) (*Session, error)
+		Revoke(ctx context.Context, sessionID, userID ID) error
+	}
+	Comments interface {
+		Create(context.Context, *Comment) error
+		GetByID(context.Context, ID) (*Comment, error)
+		GetByPostID(ctx context.Context, postID ID, parentID *ID, page Page) ([]CommentWithUser, *Cursor, error)
+		Update(ctx context.Context, commentID, userID ID, content string) error
+		Delete(ctx context.Context, commentID, userID ID) error
+	}
+	Reactions interface {
+		Add(ctx context.Context, postID, userID ID, kind string) (bool, error)
+		Remove(ctx context.Context, postID, userID ID, kind string) (bool, error)
+		GetKindsByUser(ctx context.Context, postIDs []ID, userID ID) (map[ID][]string, error)
+	}
+	Tags interface {
+		GetTrending(ctx context.Context, window time.Duration, limit int64) ([]TagCount, error)
+	}
+	Follows interface {
+		Follow(ctx context.Context, followerID, followeeID ID) error
+		Unfollow(ctx context.Context, followerID, followeeID ID) error
+		GetFollowers(context.Context, ID, Page) ([]FollowWithUser, *Cursor, error)
+		GetFollowing(context.Context, ID, Page) ([]FollowWithUser, *Cursor, error)
+		GetCounts(ctx context.Context, userID ID) (followers, following int64, err error)
 	}
 }
 
-func NewStorage(client *mongo.Client, dbName string) Storage {
+// NewStorage returns Mongo-backed stores for dbName. fanout enables
+// fan-out-on-write home timelines; pass nil to build feeds at read time.
+func NewStorage(client *mongo.Client, dbName string, fanout *TimelineFanout) Storage {
 	db := client.Database(dbName)
 	usersCollection := db.Collection("users")
 	postsCollection := db.Collection("posts")
+	sessionsCollection := db.Collection("sessions")
+	followsCollection := db.Collection("follows")
+	timelinesCollection := db.Collection("timelines")
+	commentsCollection := db.Collection("comments")
+	reactionsCollection := db.Collection("reactions")
 
-	return Storage{
+	return WrapErrors(Storage{
 		Users: &UserStore{
-			collection:      usersCollection,
-			postsCollection: postsCollection,
+			collection:          usersCollection,
+			postsCollection:     postsCollection,
+			sessionsCollection:  sessionsCollection,
+			followsCollection:   followsCollection,
+			timelinesCollection: timelinesCollection,
+			commentsCollection:  commentsCollection,
+			reactionsCollection: reactionsCollection,
 		},
 		Posts: &PostStore{
-			collection:      postsCollection,
-			usersCollection: usersCollection,
+			collection:          postsCollection,
+			usersCollection:     usersCollection,
+			followsCollection:   followsCollection,
+			commentsCollection:  commentsCollection,
+			reactionsCollection: reactionsCollection,
+			fanout:              fanout,
 		},
-	}
+		Sessions: &SessionStore{
+			collection: sessionsCollection,
+		},
+		Comments: &CommentStore{
+			collection:      commentsCollection,
+			postsCollection: postsCollection,
+		},
+		Reactions: &ReactionStore{
+			client:          client,
+			collection:      reactionsCollection,
+			postsCollection: postsCollection,
+		},
+		Tags: &TagStore{
+			postsCollection: postsCollection,
+		},
+		Follows: &FollowStore{
+			collection: followsCollection,
+			fanout:     fanout,
+		},
+	}, wrapMongoError)
 }
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MaxTags is the most tags a post may carry
+const MaxTags = 10
+
+// trendingCacheTTL is how long trending tag results are reused
+const trendingCacheTTL = 5 * time.Minute
+
+// validTag matches normalized tags: lowercase letters, digits, '-' and '_',
+// starting with a letter or digit, at most 30 characters
+var validTag = regexp.MustCompile(`^[\p{Ll}\p{Lo}0-9][\p{Ll}\p{Lo}0-9_-]{0,29}$`)
+
+// NormalizeTags lowercases and trims tags, strips a leading '#', drops
+// duplicates and empty entries, and validates the result
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !IsValidTag(tag) {
+			return nil, fmt.Errorf("invalid tag %q: use up to 30 letters, digits, '-' or '_'", tag)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("a post can have at most %d tags", MaxTags)
+	}
+
+	return normalized, nil
+}
+
+// IsValidTag reports whether a normalized tag may be stored
+func IsValidTag(tag string) bool {
+	return validTag.MatchString(tag)
+}
+
+// NormalizeTag returns the canonical form of a single tag
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// TagCount is a tag and the number of posts using it
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+type trendingEntry struct {
+	tags      []TagCount
+	expiresAt time.Time
+}
+
+// TagStore answers tag queries over the posts collection
+type TagStore struct {
+	postsCollection *mongo.Collection
+
+	mu       sync.Mutex
+	trending map[string]trendingEntry // Cached by window and limit
+}
+
+// GetTrending returns the limit most used tags on posts created within
+// window, most used first. Results are cached for a few minutes.
+func (s *TagStore) GetTrending(ctx context.Context, window time.Duration, limit int64) ([]TagCount, error) {
+	key := fmt.Sprintf("%s/%d", window, limit)
+	now := time.Now()
+
+	s.mu.Lock()
+	if entry, ok := s.trending[key]; ok && now.Before(entry.expiresAt) {
+		s.mu.Unlock()
+		return entry.tags, nil
+	}
+	s.mu.Unlock()
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{"created_at": bson.M{"$gte": now.Add(-window)}},
+		},
+		{
+			"$unwind": "$tags",
+		},
+		{
+			"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}},
+		},
+		{
+			"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}},
+		},
+		{
+			"$limit": limit,
+		},
+	}
+
+	cursor, err := s.postsCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tags := []TagCount{}
+	if err = cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.trending == nil {
+		s.trending = make(map[string]trendingEntry)
+	}
+	// Drop expired entries so arbitrary windows cannot grow the cache forever
+	for k, entry := range s.trending {
+		if now.After(entry.expiresAt) {
+			delete(s.trending, k)
+		}
+	}
+	s.trending[key] = trendingEntry{tags: tags, expiresAt: now.Add(trendingCacheTTL)}
+	s.mu.Unlock()
+
+	return tags, nil
+}
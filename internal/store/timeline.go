@@ -0,0 +1,320 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fanoutTimeout bounds how long a single post's fan-out may take
+const fanoutTimeout = 30 * time.Second
+
+// fanoutBatchSize is how many timeline updates are sent per bulk write
+const fanoutBatchSize = 500
+
+// TimelineEntry references a post in a user's precomputed timeline
+type TimelineEntry struct {
+	PostID    ID        `json:"post_id" bson:"post_id"`
+	AuthorID  ID        `json:"author_id" bson:"author_id"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Timeline is a user's bounded bucket of recent posts from people they
+// follow, newest first. The document ID is the owning user's ID.
+type Timeline struct {
+	UserID  ID              `json:"user_id" bson:"_id"`
+	Entries []TimelineEntry `json:"entries" bson:"entries"`
+}
+
+// timelinePull records that posts by an author up to Until were not pushed
+// into timelines, because the author was above the follower threshold or
+// the fan-out failed. Feeds pull those posts when they are read.
+type timelinePull struct {
+	AuthorID ID        `bson:"_id"`
+	Until    time.Time `bson:"until"`
+}
+
+// FanoutConfig configures fan-out-on-write timelines
+type FanoutConfig struct {
+	Workers           int   // Number of background workers
+	QueueSize         int   // Posts waiting for fan-out before new ones are dropped
+	TimelineSize      int   // Entries kept per user timeline
+	FollowerThreshold int64 // Authors with more followers are pulled at read time instead
+}
+
+// TimelineFanout pushes new posts into the timelines of the author's
+// followers using a pool of background workers. Posts by authors above the
+// follower threshold are not pushed but recorded in timeline_pulls, and
+// merged in when the feed is read. Following or unfollowing an author adds
+// or removes their recent posts.
+type TimelineFanout struct {
+	timelines *mongo.Collection
+	pulls     *mongo.Collection
+	follows   *mongo.Collection
+	posts     *mongo.Collection
+	cfg       FanoutConfig
+
+	jobs   chan Post
+	wg     sync.WaitGroup
+	mu     sync.RWMutex // Held for writing to close jobs, for reading to send
+	closed bool
+}
+
+// NewTimelineFanout creates a fan-out worker pool for db and starts its workers
+func NewTimelineFanout(db *mongo.Database, cfg FanoutConfig) *TimelineFanout {
+	f := &TimelineFanout{
+		timelines: db.Collection("timelines"),
+		pulls:     db.Collection("timeline_pulls"),
+		follows:   db.Collection("follows"),
+		posts:     db.Collection("posts"),
+		cfg:       cfg,
+		jobs:      make(chan Post, cfg.QueueSize),
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		f.wg.Add(1)
+		go f.work()
+	}
+
+	return f
+}
+
+// Enqueue schedules post for fan-out without blocking. It reports false if
+// the queue is full or the fan-out is closed, in which case the post is
+// pulled into feeds when they are read.
+func (f *TimelineFanout) Enqueue(post Post) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return false
+	}
+
+	select {
+	case f.jobs <- post:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting posts and waits for queued ones to be fanned out,
+// or for ctx to be done
+func (f *TimelineFanout) Close(ctx context.Context) error {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.jobs)
+	}
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *TimelineFanout) work() {
+	defer f.wg.Done()
+	for post := range f.jobs {
+		ctx, cancel := context.WithTimeout(context.Background(), fanoutTimeout)
+		if err := f.fanout(ctx, post); err != nil {
+			slog.Error("Fanning out post", "post_id", post.ID.String(), "error", err)
+			// Some followers may have missed it, so have their feeds pull it
+			if err := f.recordPull(ctx, post); err != nil {
+				slog.Error("Recording post to pull", "post_id", post.ID.String(), "error", err)
+			}
+		}
+		cancel()
+	}
+}
+
+// fanout pushes post into the timeline of every follower of its author.
+// Posts by authors above the threshold are recorded to be pulled instead,
+// so feeds keep them whatever the author's follower count is later.
+func (f *TimelineFanout) fanout(ctx context.Context, post Post) error {
+	followers, err := f.follows.CountDocuments(ctx, bson.M{"followee_id": post.UserID})
+	if err != nil {
+		return err
+	}
+	if followers > f.cfg.FollowerThreshold {
+		return f.recordPull(ctx, post)
+	}
+	return f.push(ctx, post)
+}
+
+// recordPull makes feeds pull the posts of post's author up to post
+func (f *TimelineFanout) recordPull(ctx context.Context, post Post) error {
+	_, err := f.pulls.UpdateOne(ctx, bson.M{"_id": post.UserID},
+		bson.M{"$max": bson.M{"until": post.CreatedAt}}, options.Update().SetUpsert(true))
+	return err
+}
+
+// push adds post to the timeline of every follower of its author
+func (f *TimelineFanout) push(ctx context.Context, post Post) error {
+
+	cursor, err := f.follows.Find(ctx, bson.M{"followee_id": post.UserID},
+		options.Find().SetProjection(bson.M{"follower_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	entry := TimelineEntry{PostID: post.ID, AuthorID: post.UserID, CreatedAt: post.CreatedAt}
+	update := bson.M{
+		"$push": bson.M{
+			"entries": bson.M{
+				"$each":  []TimelineEntry{entry},
+				"$sort":  bson.M{"created_at": -1},
+				"$slice": f.cfg.TimelineSize,
+			},
+		},
+	}
+
+	models := make([]mongo.WriteModel, 0, fanoutBatchSize)
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		_, err := f.timelines.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		models = models[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var follow Follow
+		if err := cursor.Decode(&follow); err != nil {
+			return err
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": follow.FollowerID}).
+			SetUpdate(update).
+			SetUpsert(true))
+		if len(models) == fanoutBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// backfill pushes the most recent posts of authorID into userID's timeline,
+// for when userID starts following them. This includes posts of authors
+// above the threshold, which were fanned out while they were below it.
+func (f *TimelineFanout) backfill(ctx context.Context, userID, authorID ID) error {
+	cursor, err := f.posts.Find(ctx, bson.M{"user_id": authorID}, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(f.cfg.TimelineSize)).
+		SetProjection(bson.M{"_id": 1, "user_id": 1, "created_at": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []TimelineEntry
+	for cursor.Next(ctx) {
+		var post Post
+		if err := cursor.Decode(&post); err != nil {
+			return err
+		}
+		entries = append(entries, TimelineEntry{PostID: post.ID, AuthorID: post.UserID, CreatedAt: post.CreatedAt})
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	_, err = f.timelines.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$push": bson.M{
+			"entries": bson.M{
+				"$each":  entries,
+				"$sort":  bson.M{"created_at": -1},
+				"$slice": f.cfg.TimelineSize,
+			},
+		},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+// removeAuthor drops the posts of authorID from userID's timeline, for when
+// userID stops following them
+func (f *TimelineFanout) removeAuthor(ctx context.Context, userID, authorID ID) error {
+	_, err := f.timelines.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$pull": bson.M{"entries": bson.M{"author_id": authorID}},
+	})
+	return err
+}
+
+// timelineEntries returns the entries of userID's precomputed timeline,
+// newest first
+func (f *TimelineFanout) timelineEntries(ctx context.Context, userID ID) ([]TimelineEntry, error) {
+	var timeline Timeline
+	err := f.timelines.FindOne(ctx, bson.M{"_id": userID}).Decode(&timeline)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return timeline.Entries, nil
+}
+
+// pulledAuthors returns the pull records of those of authorIDs with posts
+// that were not pushed
+func (f *TimelineFanout) pulledAuthors(ctx context.Context, authorIDs []ID) ([]timelinePull, error) {
+	cursor, err := f.pulls.Find(ctx, bson.M{"_id": bson.M{"$in": authorIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pulls []timelinePull
+	if err := cursor.All(ctx, &pulls); err != nil {
+		return nil, err
+	}
+	return pulls, nil
+}
+
+// cachedFeedFilter matches the posts of userID's feed that are no older
+// than the oldest of their timeline entries: the pushed posts, posts that
+// were recorded to be pulled and the user's own posts. Followed authors
+// are only looked up through entries and pulls, never as a whole, so the
+// query doesn't touch the posts of every followee.
+func cachedFeedFilter(userID ID, entries []TimelineEntry, pulls []timelinePull) bson.M {
+	pushed := make([]ID, 0, len(entries))
+	for _, e := range entries {
+		pushed = append(pushed, e.PostID)
+	}
+
+	sources := bson.A{
+		bson.M{"_id": bson.M{"$in": pushed}},
+		bson.M{"user_id": userID},
+	}
+	for _, p := range pulls {
+		sources = append(sources, bson.M{"user_id": p.AuthorID, "created_at": bson.M{"$lte": p.Until}})
+	}
+
+	// Entries are newest first; older posts are served by the pull query
+	oldest := entries[len(entries)-1].CreatedAt
+	return bson.M{"$or": sources, "created_at": bson.M{"$gte": oldest}}
+}
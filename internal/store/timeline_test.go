@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDatabase connects to the Mongo server at GOPHERSO_TEST_MONGO_URI and
+// returns a fresh database that is dropped when the test ends. The test is
+// skipped if the variable isn't set.
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("GOPHERSO_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("GOPHERSO_TEST_MONGO_URI not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+	db := client.Database("gopherso_test_" + NewID().String())
+	t.Cleanup(func() {
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+func TestEnqueueAfterCloseIsRejected(t *testing.T) {
+	// Connecting is lazy, so no server is needed
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+
+	f := NewTimelineFanout(client.Database("gopherso"), FanoutConfig{Workers: 1, QueueSize: 1})
+	if err := f.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if f.Enqueue(Post{ID: NewID()}) {
+		t.Error("Enqueue after Close = true, want false")
+	}
+}
+
+func TestFeedKeepsPostsOfAuthorsCrossingThreshold(t *testing.T) {
+	db := testDatabase(t)
+	ctx := context.Background()
+
+	// No workers: queued posts are fanned out by the test itself
+	f := NewTimelineFanout(db, FanoutConfig{QueueSize: 10, TimelineSize: 3, FollowerThreshold: 1})
+	s := NewStorage(db.Client(), db.Name(), f)
+
+	post := func(authorID ID, title string) ID {
+		t.Helper()
+		p := &Post{Title: title, Content: title, UserID: authorID}
+		if err := s.Posts.Create(ctx, p); err != nil {
+			t.Fatalf("creating %s: %v", title, err)
+		}
+		if err := f.fanout(ctx, <-f.jobs); err != nil {
+			t.Fatalf("fanning out %s: %v", title, err)
+		}
+		time.Sleep(time.Millisecond) // Keep created_at strictly ordered
+		return p.ID
+	}
+	follow := func(followerID, followeeID ID) {
+		t.Helper()
+		if err := s.Follows.Follow(ctx, followerID, followeeID); err != nil {
+			t.Fatalf("following: %v", err)
+		}
+	}
+
+	user := func(name string) ID {
+		t.Helper()
+		u := &User{Username: name, Email: name + "@example.com"}
+		if err := s.Users.Create(ctx, u); err != nil {
+			t.Fatalf("creating %s: %v", name, err)
+		}
+		return u.ID
+	}
+
+	reader, other, author := user("reader"), user("other"), user("author")
+	follow(reader, author)
+	light0 := post(author, "light 0") // Trimmed from the timeline
+	light1 := post(author, "light 1")
+	light2 := post(author, "light 2")
+
+	// Above the threshold when posting, so this one is not pushed...
+	follow(other, author)
+	heavy := post(author, "heavy")
+
+	// ...and stays in the feed once the author drops below it
+	if err := s.Follows.Unfollow(ctx, other, author); err != nil {
+		t.Fatalf("unfollowing: %v", err)
+	}
+	light3 := post(author, "light 3")
+
+	want := []ID{light3, heavy, light2, light1, light0}
+	for _, limit := range []int64{10, 2, 1} {
+		var got []ID
+		page := Page{Limit: limit}
+		for {
+			posts, next, err := s.Posts.GetFeed(ctx, reader, page)
+			if err != nil {
+				t.Fatalf("GetFeed: %v", err)
+			}
+			for _, p := range posts {
+				got = append(got, p.ID)
+			}
+			if next == nil {
+				break
+			}
+			page.After = next
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("feed with limit %d = %v, want %v", limit, got, want)
+		}
+	}
+}
+
+func TestCachedFeedFilterOnlyNamesPulledAuthors(t *testing.T) {
+	reader, pushedAuthor, heavyAuthor := NewID(), NewID(), NewID()
+	entries := []TimelineEntry{{PostID: NewID(), AuthorID: pushedAuthor, CreatedAt: time.Now()}}
+	pulls := []timelinePull{{AuthorID: heavyAuthor, Until: time.Now()}}
+
+	filter, err := bson.MarshalExtJSON(cachedFeedFilter(reader, entries, pulls), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pushed posts are matched by ID, so the query never scans the posts of
+	// followees that are fanned out to
+	if strings.Contains(string(filter), pushedAuthor.String()) {
+		t.Errorf("filter %s looks up the posts of a pushed author", filter)
+	}
+	for _, id := range []ID{entries[0].PostID, heavyAuthor, reader} {
+		if !strings.Contains(string(filter), id.String()) {
+			t.Errorf("filter %s doesn't name %s", filter, id)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans of store operations
+const tracerName = "github.com/Nutan-Kum12/Gopherso/internal/store"
+
+// Trace returns s with every store wrapped to record a span per operation,
+// parented to the span in the operation's context
+func Trace(s Storage, tp trace.TracerProvider) Storage {
+	t := &storeTracer{tracer: tp.Tracer(tracerName)}
+	inner := s
+	s.Posts = &tracedPosts{inner: inner, tracer: t}
+	s.Users = &tracedUsers{inner: inner, tracer: t}
+	s.Sessions = &tracedSessions{inner: inner, tracer: t}
+	s.Comments = &tracedComments{inner: inner, tracer: t}
+	s.Reactions = &tracedReactions{inner: inner, tracer: t}
+	s.Tags = &tracedTags{inner: inner, tracer: t}
+	s.Follows = &tracedFollows{inner: inner, tracer: t}
+	return s
+}
+
+// storeTracer starts and ends the spans of store operations
+type storeTracer struct {
+	tracer trace.Tracer
+}
+
+func (t *storeTracer) start(ctx context.Context, store, op string) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, store+"."+op)
+}
+
+// end ends span, recording err. Errors that are expected outcomes of a
+// request, such as a missing document, don't mark the span as failed.
+func (t *storeTracer) end(span trace.Span, err error) {
+	if err != nil {
+		kind := errorKind(err)
+		span.SetAttributes(attribute.String("error.type", kind))
+		if kind == "internal" {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+// tracedPosts records a span around each operation of the Posts store of inner
+type tracedPosts struct {
+	inner  Storage
+	tracer *storeTracer
+}
+
+func (s *tracedPosts) Create(ctx context.Context, post *Post) error {
+	ctx, span := s.tracer.start(ctx, "posts", "Create")
+	err := s.inner.Posts.Create(ctx, post)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedPosts) GetByID(ctx context.Context, id ID) (*Post, error) {
+	ctx, span := s.tracer.start(ctx, "posts", "GetByID")
+	post, err := s.inner.Posts.GetByID(ctx, id)
+	s.tracer.end(span, err)
+	return post, err
+}
+
+func (s *tracedPosts) GetByUserID(ctx context.Context, userID ID, page Page) ([]Post, *Cursor, error) {
+	ctx, span := s.tracer.start(ctx, "posts", "GetByUserID")
+	posts, cursor, err := s.inner.Posts.GetByUserID(ctx, userID, page)
+	s.tracer.end(span, err)
+	return posts, cursor, err
+}
+
+func (s *tracedPosts) GetWithUser(ctx context.Context, id ID) (*PostWithUser, error) {
+	ctx, span := s.tracer.start(ctx, "posts", "GetWithUser")
+	post, err := s.inner.Posts.GetWithUser(ctx, id)
+	s.tracer.end(span, err)
+	return post, err
+}
+
+func (s *tracedPosts) GetAllWithUsers(ctx context.Context, page Page) ([]PostWithUser, *Cursor, error) {
+	ctx, span := s.tracer.start(ctx, "posts", "GetAllWithUsers")
+	posts, cursor, err := s.inner.Posts.GetAllWithUsers(ctx, page)
+	s.tracer.end(span, err)
+	return posts, cursor, err
+}
+
+func (s *tracedPosts) GetFeed(ctx context.Context, userID ID, page Page) ([]PostWithUser, *Cursor, error) {
+	ctx, span := s.tracer.start(ctx, "posts", "GetFeed")
+	posts, cursor, err := s.inner.Posts.GetFeed(ctx, userID, page)
+	s.tracer.end(span, err)
+	return posts, cursor, err
+}
+
+func (s *tracedPosts) GetByTag(ctx context.Context, tag string, page Page) ([]PostWithUser, *Cursor, error) {
+	ctx, span := s.tracer.start(ctx, "posts", "GetByTag")
+	posts, cursor, err := s.inner.Posts.GetByTag(ctx, tag, page)
+	s.tracer.end(span, err)
+	return posts, cursor, err
+}
+
+func (s *tracedPosts) Search(ctx context.Context, search PostSearch) ([]PostSearchResult, error) {
+	ctx, span := s.tracer.start(ctx, "posts", "Search")
+	results, err := s.inner.Posts.Search(ctx, search)
+	s.tracer.end(span, err)
+	return results, err
+}
+
+func (s *tracedPosts) Update(ctx context.Context, postID, userID ID, update PostUpdate) error {
+	ctx, span := s.tracer.start(ctx, "posts", "Update")
+	err := s.inner.Posts.Update(ctx, postID, userID, update)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedPosts) Delete(ctx context.Context, postID, userID ID) error {
+	ctx, span := s.tracer.start(ctx, "posts", "Delete")
+	err := s.inner.Posts.Delete(ctx, postID, userID)
+	s.tracer.end(span, err)
+	return err
+}
+
+// tracedUsers records a span around each operation of the Users store of inner
+type tracedUsers struct {
+	inner  Storage
+	tracer *storeTracer
+}
+
+func (s *tracedUsers) Create(ctx context.Context, user *User) error {
+	ctx, span := s.tracer.start(ctx, "users", "Create")
+	err := s.inner.Users.Create(ctx, user)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedUsers) GetByID(ctx context.Context, id ID) (*User, error) {
+	ctx, span := s.tracer.start(ctx, "users", "GetByID")
+	user, err := s.inner.Users.GetByID(ctx, id)
+	s.tracer.end(span, err)
+	return user, err
+}
+
+func (s *tracedUsers) GetByEmail(ctx context.Context, email string) (*User, error) {
+	ctx, span := s.tracer.start(ctx, "users", "GetByEmail")
+	user, err := s.inner.Users.GetByEmail(ctx, email)
+	s.tracer.end(span, err)
+	return user, err
+}
+
+func (s *tracedUsers) GetWithPosts(ctx context.Context, id ID, page Page) (*UserWithPosts, *Cursor, error) {
+	ctx, span := s.tracer.start(ctx, "users", "GetWithPosts")
+	user, cursor, err := s.inner.Users.GetWithPosts(ctx, id, page)
+	s.tracer.end(span, err)
+	return user, cursor, err
+}
+
+func (s *tracedUsers) GetPostsCount(ctx context.Context, id ID) (int64, error) {
+	ctx, span := s.tracer.start(ctx, "users", "GetPostsCount")
+	count, err := s.inner.Users.GetPostsCount(ctx, id)
+	s.tracer.end(span, err)
+	return count, err
+}
+
+func (s *tracedUsers) VerifyCredentials(ctx context.Context, email, password string) (*User, error) {
+	ctx, span := s.tracer.start(ctx, "users", "VerifyCredentials")
+	user, err := s.inner.Users.VerifyCredentials(ctx, email, password)
+	s.tracer.end(span, err)
+	return user, err
+}
+
+func (s *tracedUsers) Update(ctx context.Context, id ID, update UserUpdate) error {
+	ctx, span := s.tracer.start(ctx, "users", "Update")
+	err := s.inner.Users.Update(ctx, id, update)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedUsers) RequestEmailChange(ctx context.Context, userID ID, newEmail, tokenHash string, expiresAt time.Time) error {
+	ctx, span := s.tracer.start(ctx, "users", "RequestEmailChange")
+	err := s.inner.Users.RequestEmailChange(ctx, userID, newEmail, tokenHash, expiresAt)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedUsers) ConfirmEmailChange(ctx context.Context, tokenHash string) (*User, error) {
+	ctx, span := s.tracer.start(ctx, "users", "ConfirmEmailChange")
+	user, err := s.inner.Users.ConfirmEmailChange(ctx, tokenHash)
+	s.tracer.end(span, err)
+	return user, err
+}
+
+func (s *tracedUsers) Delete(ctx context.Context, id ID) error {
+	ctx, span := s.tracer.start(ctx, "users", "Delete")
+	err := s.inner.Users.Delete(ctx, id)
+	s.tracer.end(span, err)
+	return err
+}
+
+// tracedSessions records a span around each operation of the Sessions store of inner
+type tracedSessions struct {
+	inner  Storage
+	tracer *storeTracer
+}
+
+func (s *tracedSessions) Create(ctx context.Context, session *Session) error {
+	ctx, span := s.tracer.start(ctx, "sessions", "Create")
+	err := s.inner.Sessions.Create(ctx, session)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedSessions) GetByID(ctx context.Context, id ID) (*Session, error) {
+	ctx, span := s.tracer.start(ctx, "sessions", "GetByID")
+	session, err := s.inner.Sessions.GetByID(ctx, id)
+	s.tracer.end(span, err)
+	return session, err
+}
+
+func (s *tracedSessions) GetActiveByUserID(ctx context.Context, userID ID) ([]Session, error) {
+	ctx, span := s.tracer.start(ctx, "sessions", "GetActiveByUserID")
+	sessions, err := s.inner.Sessions.GetActiveByUserID(ctx, userID)
+	s.tracer.end(span, err)
+	return sessions, err
+}
+
+func (s *tracedSessions) Rotate(ctx context.Context, sessionID ID, tokenHash, newHash string, expiresAt time.Time) (*Session, error) {
+	ctx, span := s.tracer.start(ctx, "sessions", "Rotate")
+	session, err := s.inner.Sessions.Rotate(ctx, sessionID, tokenHash, newHash, expiresAt)
+	s.tracer.end(span, err)
+	return session, err
+}
+
+func (s *tracedSessions) Revoke(ctx context.Context, sessionID, userID ID) error {
+	ctx, span := s.tracer.start(ctx, "sessions", "Revoke")
+	err := s.inner.Sessions.Revoke(ctx, sessionID, userID)
+	s.tracer.end(span, err)
+	return err
+}
+
+// tracedComments records a span around each operation of the Comments store of inner
+type tracedComments struct {
+	inner  Storage
+	tracer *storeTracer
+}
+
+func (s *tracedComments) Create(ctx context.Context, comment *Comment) error {
+	ctx, span := s.tracer.start(ctx, "comments", "Create")
+	err := s.inner.Comments.Create(ctx, comment)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedComments) GetByID(ctx context.Context, id ID) (*Comment, error) {
+	ctx, span := s.tracer.start(ctx, "comments", "GetByID")
+	comment, err := s.inner.Comments.GetByID(ctx, id)
+	s.tracer.end(span, err)
+	return comment, err
+}
+
+func (s *tracedComments) GetByPostID(ctx context.Context, postID ID, parentID *ID, page Page) ([]CommentWithUser, *Cursor, error) {
+	ctx, span := s.tracer.start(ctx, "comments", "GetByPostID")
+	comments, cursor, err := s.inner.Comments.GetByPostID(ctx, postID, parentID, page)
+	s.tracer.end(span, err)
+	return comments, cursor, err
+}
+
+func (s *tracedComments) Update(ctx context.Context, commentID, userID ID, content string) error {
+	ctx, span := s.tracer.start(ctx, "comments", "Update")
+	err := s.inner.Comments.Update(ctx, commentID, userID, content)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedComments) Delete(ctx context.Context, commentID, userID ID) error {
+	ctx, span := s.tracer.start(ctx, "comments", "Delete")
+	err := s.inner.Comments.Delete(ctx, commentID, userID)
+	s.tracer.end(span, err)
+	return err
+}
+
+// tracedReactions records a span around each operation of the Reactions store of inner
+type tracedReactions struct {
+	inner  Storage
+	tracer *storeTracer
+}
+
+func (s *tracedReactions) Add(ctx context.Context, postID, userID ID, kind string) (bool, error) {
+	ctx, span := s.tracer.start(ctx, "reactions", "Add")
+	changed, err := s.inner.Reactions.Add(ctx, postID, userID, kind)
+	s.tracer.end(span, err)
+	return changed, err
+}
+
+func (s *tracedReactions) Remove(ctx context.Context, postID, userID ID, kind string) (bool, error) {
+	ctx, span := s.tracer.start(ctx, "reactions", "Remove")
+	changed, err := s.inner.Reactions.Remove(ctx, postID, userID, kind)
+	s.tracer.end(span, err)
+	return changed, err
+}
+
+func (s *tracedReactions) GetKindsByUser(ctx context.Context, postIDs []ID, userID ID) (map[ID][]string, error) {
+	ctx, span := s.tracer.start(ctx, "reactions", "GetKindsByUser")
+	kinds, err := s.inner.Reactions.GetKindsByUser(ctx, postIDs, userID)
+	s.tracer.end(span, err)
+	return kinds, err
+}
+
+// tracedTags records a span around each operation of the Tags store of inner
+type tracedTags struct {
+	inner  Storage
+	tracer *storeTracer
+}
+
+func (s *tracedTags) GetTrending(ctx context.Context, window time.Duration, limit int64) ([]TagCount, error) {
+	ctx, span := s.tracer.start(ctx, "tags", "GetTrending")
+	tags, err := s.inner.Tags.GetTrending(ctx, window, limit)
+	s.tracer.end(span, err)
+	return tags, err
+}
+
+// tracedFollows records a span around each operation of the Follows store of inner
+type tracedFollows struct {
+	inner  Storage
+	tracer *storeTracer
+}
+
+func (s *tracedFollows) Follow(ctx context.Context, followerID, followeeID ID) error {
+	ctx, span := s.tracer.start(ctx, "follows", "Follow")
+	err := s.inner.Follows.Follow(ctx, followerID, followeeID)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedFollows) Unfollow(ctx context.Context, followerID, followeeID ID) error {
+	ctx, span := s.tracer.start(ctx, "follows", "Unfollow")
+	err := s.inner.Follows.Unfollow(ctx, followerID, followeeID)
+	s.tracer.end(span, err)
+	return err
+}
+
+func (s *tracedFollows) GetFollowers(ctx context.Context, userID ID, page Page) ([]FollowWithUser, *Cursor, error) {
+	ctx, span := s.tracer.start(ctx, "follows", "GetFollowers")
+	follows, cursor, err := s.inner.Follows.GetFollowers(ctx, userID, page)
+	s.tracer.end(span, err)
+	return follows, cursor, err
+}
+
+func (s *tracedFollows) GetFollowing(ctx context.Context, userID ID, page Page) ([]FollowWithUser, *Cursor, error) {
+	ctx, span := s.tracer.start(ctx, "follows", "GetFollowing")
+	follows, cursor, err := s.inner.Follows.GetFollowing(ctx, userID, page)
+	s.tracer.end(span, err)
+	return follows, cursor, err
+}
+
+func (s *tracedFollows) GetCounts(ctx context.Context, userID ID) (int64, int64, error) {
+	ctx, span := s.tracer.start(ctx, "follows", "GetCounts")
+	followers, following, err := s.inner.Follows.GetCounts(ctx, userID)
+	s.tracer.end(span, err)
+	return followers, following, err
+}
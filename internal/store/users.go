@@ -2,21 +2,47 @@ package store
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	// ErrInvalidCredentials is returned when an email/password pair does
+	// not match any user
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrInvalidEmailChangeToken is returned when an email change token is
+	// unknown or expired
+	ErrInvalidEmailChangeToken = errors.New("invalid or expired email change token")
+)
+
+// caseInsensitive matches the collation of the unique email and username
+// indexes so lookups can use them
+var caseInsensitive = &options.Collation{Locale: "en", Strength: 2}
+
+// UserUpdate holds the profile fields a user can change. Nil fields are
+// left untouched.
+type UserUpdate struct {
+	Username *string
+	Bio      *string
+}
+
 type User struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Username  string             `json:"username" bson:"username"`
-	Email     string             `json:"email" bson:"email"`
-	Password  string             `json:"-" bson:"password"`
-	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	ID        ID        `json:"id" bson:"_id,omitempty"`
+	Username  string    `json:"username" bson:"username"`
+	Email     string    `json:"email" bson:"email"`
+	Password  string    `json:"-" bson:"password"`
+	Bio       string    `json:"bio" bson:"bio,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+
+	// Pending email change awaiting confirmation
+	PendingEmail         string     `json:"-" bson:"pending_email,omitempty"`
+	EmailChangeTokenHash string     `json:"-" bson:"email_change_token_hash,omitempty"`
+	EmailChangeExpiresAt *time.Time `json:"-" bson:"email_change_expires_at,omitempty"`
 }
 
 // UserWithPosts represents a user with their posts
@@ -26,73 +52,260 @@ type UserWithPosts struct {
 }
 
 type UserStore struct {
-	collection      *mongo.Collection
-	postsCollection *mongo.Collection
+	collection          *mongo.Collection
+	postsCollection     *mongo.Collection
+	sessionsCollection  *mongo.Collection
+	followsCollection   *mongo.Collection
+	timelinesCollection *mongo.Collection
+	commentsCollection  *mongo.Collection
+	reactionsCollection *mongo.Collection
 }
 
 func (s *UserStore) Create(ctx context.Context, user *User) error {
-	user.ID = primitive.NewObjectID()
+	user.ID = NewID()
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	result, err := s.collection.InsertOne(ctx, user)
+	_, err := s.collection.InsertOne(ctx, user)
 	if err != nil {
-		return err
+		return mapDuplicateKey(err)
 	}
 
-	user.ID = result.InsertedID.(primitive.ObjectID)
 	return nil
 }
 
 // GetByID retrieves a user by their ID
-func (s *UserStore) GetByID(ctx context.Context, userID primitive.ObjectID) (*User, error) {
+func (s *UserStore) GetByID(ctx context.Context, userID ID) (*User, error) {
 	var user User
 	err := s.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, mapNotFound(err)
 	}
 	return &user, nil
 }
 
-// GetByEmail retrieves a user by their email
+// GetByEmail retrieves a user by their email, ignoring case
 func (s *UserStore) GetByEmail(ctx context.Context, email string) (*User, error) {
 	var user User
-	err := s.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	err := s.collection.FindOne(ctx, bson.M{"email": email},
+		options.FindOne().SetCollation(caseInsensitive)).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, mapNotFound(err)
 	}
 	return &user, nil
 }
 
-// GetWithPosts retrieves a user with all their posts
-func (s *UserStore) GetWithPosts(ctx context.Context, userID primitive.ObjectID) (*UserWithPosts, error) {
+// GetWithPosts retrieves a user with a page of their posts, newest first
+func (s *UserStore) GetWithPosts(ctx context.Context, userID ID, page Page) (*UserWithPosts, *Cursor, error) {
 	// First get the user
 	user, err := s.GetByID(ctx, userID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Then get all posts by this user
-	cursor, err := s.postsCollection.Find(ctx, bson.M{"user_id": userID},
-		options.Find().SetSort(bson.D{{"created_at", -1}}))
+	// Then get a page of posts by this user
+	cursor, err := s.postsCollection.Find(ctx, page.filter(bson.M{"user_id": userID}),
+		options.Find().SetSort(pageSort).SetLimit(page.fetchLimit()))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var posts []Post
 	if err = cursor.All(ctx, &posts); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	posts, next := trimPage(page, posts, Post.cursor)
 	return &UserWithPosts{
 		User:  *user,
 		Posts: posts,
-	}, nil
+	}, next, nil
 }
 
 // GetPostsCount returns the number of posts for a user
-func (s *UserStore) GetPostsCount(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+func (s *UserStore) GetPostsCount(ctx context.Context, userID ID) (int64, error) {
 	count, err := s.postsCollection.CountDocuments(ctx, bson.M{"user_id": userID})
 	return count, err
 }
+
+// VerifyCredentials returns the user matching email if password is correct
+func (s *UserStore) VerifyCredentials(ctx context.Context, email, password string) (*User, error) {
+	return CheckCredentials(ctx, email, password, s.GetByEmail, s.updatePasswordIfUnchanged)
+}
+
+// updatePasswordIfUnchanged replaces the user's password hash if it is still oldHash
+func (s *UserStore) updatePasswordIfUnchanged(ctx context.Context, userID ID, oldHash, newHash string) error {
+	filter := bson.M{"_id": userID, "password": oldHash}
+	update := bson.M{"$set": bson.M{"password": newHash}}
+	_, err := s.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// Update updates a user's profile
+func (s *UserStore) Update(ctx context.Context, userID ID, changes UserUpdate) error {
+	updateData := bson.M{"updated_at": time.Now()}
+	if changes.Username != nil {
+		updateData["username"] = *changes.Username
+	}
+	if changes.Bio != nil {
+		updateData["bio"] = *changes.Bio
+	}
+
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": updateData})
+	if err != nil {
+		return mapDuplicateKey(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RequestEmailChange records newEmail as pending until the token whose hash
+// is tokenHash is confirmed. Any earlier pending change is replaced.
+func (s *UserStore) RequestEmailChange(ctx context.Context, userID ID, newEmail, tokenHash string, expiresAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"pending_email":           newEmail,
+			"email_change_token_hash": tokenHash,
+			"email_change_expires_at": expiresAt,
+		},
+	}
+
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange swaps in the pending email of the user holding
+// tokenHash. The token can only be used once.
+func (s *UserStore) ConfirmEmailChange(ctx context.Context, tokenHash string) (*User, error) {
+	now := time.Now()
+
+	filter := bson.M{
+		"email_change_token_hash": tokenHash,
+		"email_change_expires_at": bson.M{"$gt": now},
+	}
+	// Aggregation pipeline update so email can be set from pending_email
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"email":      "$pending_email",
+			"updated_at": now,
+		}}},
+		{{Key: "$unset", Value: bson.A{
+			"pending_email",
+			"email_change_token_hash",
+			"email_change_expires_at",
+		}}},
+	}
+
+	var user User
+	err := s.collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidEmailChangeToken
+	}
+	if err != nil {
+		return nil, mapDuplicateKey(err)
+	}
+
+	return &user, nil
+}
+
+// Delete deletes a user, also dropping their timeline. There is no
+// transaction, so dependents are removed first and a failure part way never
+// leaves posts pointing at a missing user.
+func (s *UserStore) Delete(ctx context.Context, userID ID) error {
+	postIDs, err := s.postsCollection.Distinct(ctx, "_id", bson.M{"user_id": userID})
+	if err != nil {
+		return err
+	}
+	if _, err := s.commentsCollection.DeleteMany(ctx, bson.M{"post_id": bson.M{"$in": postIDs}}); err != nil {
+		return err
+	}
+
+	if _, err := s.reactionsCollection.DeleteMany(ctx, bson.M{"post_id": bson.M{"$in": postIDs}}); err != nil {
+		return err
+	}
+
+	if _, err := s.postsCollection.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return err
+	}
+
+	if err := s.deleteReactions(ctx, userID); err != nil {
+		return err
+	}
+
+	anonymize := bson.M{"$set": bson.M{"user_id": ID(""), "content": ""}}
+	if _, err := s.commentsCollection.UpdateMany(ctx, bson.M{"user_id": userID}, anonymize); err != nil {
+		return err
+	}
+
+	if _, err := s.sessionsCollection.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return err
+	}
+
+	follows := bson.M{"$or": bson.A{
+		bson.M{"follower_id": userID},
+		bson.M{"followee_id": userID},
+	}}
+	if _, err := s.followsCollection.DeleteMany(ctx, follows); err != nil {
+		return err
+	}
+
+	if _, err := s.timelinesCollection.DeleteOne(ctx, bson.M{"_id": userID}); err != nil {
+		return err
+	}
+
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": userID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// deleteReactions removes the user's reactions on other users' posts and
+// decrements the counters they contributed to
+func (s *UserStore) deleteReactions(ctx context.Context, userID ID) error {
+	cursor, err := s.reactionsCollection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var reactions []Reaction
+	if err = cursor.All(ctx, &reactions); err != nil {
+		return err
+	}
+	if len(reactions) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(reactions))
+	for _, r := range reactions {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": r.PostID}).
+			SetUpdate(bson.M{"$inc": bson.M{"reactions." + r.Kind: -1}}))
+	}
+	if _, err := s.postsCollection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return err
+	}
+
+	_, err = s.reactionsCollection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
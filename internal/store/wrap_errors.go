@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// WrapErrors returns s with every store wrapped so the errors its
+// operations return pass through wrap. Backends use it to type driver
+// errors in one place instead of in every method.
+func WrapErrors(s Storage, wrap func(error) error) Storage {
+	inner := s
+	s.Posts = &errorWrappedPosts{inner: inner, wrap: wrap}
+	s.Users = &errorWrappedUsers{inner: inner, wrap: wrap}
+	s.Sessions = &errorWrappedSessions{inner: inner, wrap: wrap}
+	s.Comments = &errorWrappedComments{inner: inner, wrap: wrap}
+	s.Reactions = &errorWrappedReactions{inner: inner, wrap: wrap}
+	s.Tags = &errorWrappedTags{inner: inner, wrap: wrap}
+	s.Follows = &errorWrappedFollows{inner: inner, wrap: wrap}
+	return s
+}
+
+type errorWrappedPosts struct {
+	inner Storage
+	wrap  func(error) error
+}
+
+func (s *errorWrappedPosts) Create(ctx context.Context, post *Post) error {
+	return s.wrap(s.inner.Posts.Create(ctx, post))
+}
+
+func (s *errorWrappedPosts) GetByID(ctx context.Context, id ID) (*Post, error) {
+	post, err := s.inner.Posts.GetByID(ctx, id)
+	return post, s.wrap(err)
+}
+
+func (s *errorWrappedPosts) GetByUserID(ctx context.Context, userID ID, page Page) ([]Post, *Cursor, error) {
+	posts, cursor, err := s.inner.Posts.GetByUserID(ctx, userID, page)
+	return posts, cursor, s.wrap(err)
+}
+
+func (s *errorWrappedPosts) GetWithUser(ctx context.Context, id ID) (*PostWithUser, error) {
+	post, err := s.inner.Posts.GetWithUser(ctx, id)
+	return post, s.wrap(err)
+}
+
+func (s *errorWrappedPosts) GetAllWithUsers(ctx context.Context, page Page) ([]PostWithUser, *Cursor, error) {
+	posts, cursor, err := s.inner.Posts.GetAllWithUsers(ctx, page)
+	return posts, cursor, s.wrap(err)
+}
+
+func (s *errorWrappedPosts) GetFeed(ctx context.Context, userID ID, page Page) ([]PostWithUser, *Cursor, error) {
+	posts, cursor, err := s.inner.Posts.GetFeed(ctx, userID, page)
+	return posts, cursor, s.wrap(err)
+}
+
+func (s *errorWrappedPosts) GetByTag(ctx context.Context, tag string, page Page) ([]PostWithUser, *Cursor, error) {
+	posts, cursor, err := s.inner.Posts.GetByTag(ctx, tag, page)
+	return posts, cursor, s.wrap(err)
+}
+
+func (s *errorWrappedPosts) Search(ctx context.Context, search PostSearch) ([]PostSearchResult, error) {
+	results, err := s.inner.Posts.Search(ctx, search)
+	return results, s.wrap(err)
+}
+
+func (s *errorWrappedPosts) Update(ctx context.Context, postID, userID ID, update PostUpdate) error {
+	return s.wrap(s.inner.Posts.Update(ctx, postID, userID, update))
+}
+
+func (s *errorWrappedPosts) Delete(ctx context.Context, postID, userID ID) error {
+	return s.wrap(s.inner.Posts.Delete(ctx, postID, userID))
+}
+
+type errorWrappedUsers struct {
+	inner Storage
+	wrap  func(error) error
+}
+
+func (s *errorWrappedUsers) Create(ctx context.Context, user *User) error {
+	return s.wrap(s.inner.Users.Create(ctx, user))
+}
+
+func (s *errorWrappedUsers) GetByID(ctx context.Context, id ID) (*User, error) {
+	user, err := s.inner.Users.GetByID(ctx, id)
+	return user, s.wrap(err)
+}
+
+func (s *errorWrappedUsers) GetByEmail(ctx context.Context, email string) (*User, error) {
+	user, err := s.inner.Users.GetByEmail(ctx, email)
+	return user, s.wrap(err)
+}
+
+func (s *errorWrappedUsers) GetWithPosts(ctx context.Context, id ID, page Page) (*UserWithPosts, *Cursor, error) {
+	user, cursor, err := s.inner.Users.GetWithPosts(ctx, id, page)
+	return user, cursor, s.wrap(err)
+}
+
+func (s *errorWrappedUsers) GetPostsCount(ctx context.Context, id ID) (int64, error) {
+	count, err := s.inner.Users.GetPostsCount(ctx, id)
+	return count, s.wrap(err)
+}
+
+func (s *errorWrappedUsers) VerifyCredentials(ctx context.Context, email, password string) (*User, error) {
+	user, err := s.inner.Users.VerifyCredentials(ctx, email, password)
+	return user, s.wrap(err)
+}
+
+func (s *errorWrappedUsers) Update(ctx context.Context, id ID, update UserUpdate) error {
+	return s.wrap(s.inner.Users.Update(ctx, id, update))
+}
+
+func (s *errorWrappedUsers) RequestEmailChange(ctx context.Context, userID ID, newEmail, tokenHash string, expiresAt time.Time) error {
+	return s.wrap(s.inner.Users.RequestEmailChange(ctx, userID, newEmail, tokenHash, expiresAt))
+}
+
+func (s *errorWrappedUsers) ConfirmEmailChange(ctx context.Context, tokenHash string) (*User, error) {
+	user, err := s.inner.Users.ConfirmEmailChange(ctx, tokenHash)
+	return user, s.wrap(err)
+}
+
+func (s *errorWrappedUsers) Delete(ctx context.Context, id ID) error {
+	return s.wrap(s.inner.Users.Delete(ctx, id))
+}
+
+type errorWrappedSessions struct {
+	inner Storage
+	wrap  func(error) error
+}
+
+func (s *errorWrappedSessions) Create(ctx context.Context, session *Session) error {
+	return s.wrap(s.inner.Sessions.Create(ctx, session))
+}
+
+func (s *errorWrappedSessions) GetByID(ctx context.Context, id ID) (*Session, error) {
+	session, err := s.inner.Sessions.GetByID(ctx, id)
+	return session, s.wrap(err)
+}
+
+func (s *errorWrappedSessions) GetActiveByUserID(ctx context.Context, userID ID) ([]Session, error) {
+	sessions, err := s.inner.Sessions.GetActiveByUserID(ctx, userID)
+	return sessions, s.wrap(err)
+}
+
+func (s *errorWrappedSessions) Rotate(ctx context.Context, sessionID ID, tokenHash, newHash string, expiresAt time.Time) (*Session, error) {
+	session, err := s.inner.Sessions.Rotate(ctx, sessionID, tokenHash, newHash, expiresAt)
+	return session, s.wrap(err)
+}
+
+func (s *errorWrappedSessions) Revoke(ctx context.Context, sessionID, userID ID) error {
+	return s.wrap(s.inner.Sessions.Revoke(ctx, sessionID, userID))
+}
+
+type errorWrappedComments struct {
+	inner Storage
+	wrap  func(error) error
+}
+
+func (s *errorWrappedComments) Create(ctx context.Context, comment *Comment) error {
+	return s.wrap(s.inner.Comments.Create(ctx, comment))
+}
+
+func (s *errorWrappedComments) GetByID(ctx context.Context, id ID) (*Comment, error) {
+	comment, err := s.inner.Comments.GetByID(ctx, id)
+	return comment, s.wrap(err)
+}
+
+func (s *errorWrappedComments) GetByPostID(ctx context.Context, postID ID, parentID *ID, page Page) ([]CommentWithUser, *Cursor, error) {
+	comments, cursor, err := s.inner.Comments.GetByPostID(ctx, postID, parentID, page)
+	return comments, cursor, s.wrap(err)
+}
+
+func (s *errorWrappedComments) Update(ctx context.Context, commentID, userID ID, content string) error {
+	return s.wrap(s.inner.Comments.Update(ctx, commentID, userID, content))
+}
+
+func (s *errorWrappedComments) Delete(ctx context.Context, commentID, userID ID) error {
+	return s.wrap(s.inner.Comments.Delete(ctx, commentID, userID))
+}
+
+type errorWrappedReactions struct {
+	inner Storage
+	wrap  func(error) error
+}
+
+func (s *errorWrappedReactions) Add(ctx context.Context, postID, userID ID, kind string) (bool, error) {
+	changed, err := s.inner.Reactions.Add(ctx, postID, userID, kind)
+	return changed, s.wrap(err)
+}
+
+func (s *errorWrappedReactions) Remove(ctx context.Context, postID, userID ID, kind string) (bool, error) {
+	changed, err := s.inner.Reactions.Remove(ctx, postID, userID, kind)
+	return changed, s.wrap(err)
+}
+
+func (s *errorWrappedReactions) GetKindsByUser(ctx context.Context, postIDs []ID, userID ID) (map[ID][]string, error) {
+	kinds, err := s.inner.Reactions.GetKindsByUser(ctx, postIDs, userID)
+	return kinds, s.wrap(err)
+}
+
+type errorWrappedTags struct {
+	inner Storage
+	wrap  func(error) error
+}
+
+func (s *errorWrappedTags) GetTrending(ctx context.Context, window time.Duration, limit int64) ([]TagCount, error) {
+	tags, err := s.inner.Tags.GetTrending(ctx, window, limit)
+	return tags, s.wrap(err)
+}
+
+type errorWrappedFollows struct {
+	inner Storage
+	wrap  func(error) error
+}
+
+func (s *errorWrappedFollows) Follow(ctx context.Context, followerID, followeeID ID) error {
+	return s.wrap(s.inner.Follows.Follow(ctx, followerID, followeeID))
+}
+
+func (s *errorWrappedFollows) Unfollow(ctx context.Context, followerID, followeeID ID) error {
+	return s.wrap(s.inner.Follows.Unfollow(ctx, followerID, followeeID))
+}
+
+func (s *errorWrappedFollows) GetFollowers(ctx context.Context, userID ID, page Page) ([]FollowWithUser, *Cursor, error) {
+	follows, cursor, err := s.inner.Follows.GetFollowers(ctx, userID, page)
+	return follows, cursor, s.wrap(err)
+}
+
+func (s *errorWrappedFollows) GetFollowing(ctx context.Context, userID ID, page Page) ([]FollowWithUser, *Cursor, error) {
+	follows, cursor, err := s.inner.Follows.GetFollowing(ctx, userID, page)
+	return follows, cursor, s.wrap(err)
+}
+
+func (s *errorWrappedFollows) GetCounts(ctx context.Context, userID ID) (int64, int64, error) {
+	followers, following, err := s.inner.Follows.GetCounts(ctx, userID)
+	return followers, following, s.wrap(err)
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// fileClient is an OTLP client appending each export request to a file as
+// a line of JSON, the format the OpenTelemetry Collector's otlpjsonfile
+// receiver reads
+type fileClient struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (c *fileClient) Start(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	c.file = file
+	return nil
+}
+
+func (c *fileClient) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.Close()
+}
+
+func (c *fileClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	line, err := marshalOTLP(&coltracepb.ExportTraceServiceRequest{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
+
+// marshalOTLP encodes req as OTLP/JSON, which differs from the standard
+// protobuf JSON mapping in encoding trace and span IDs as hex, not base64,
+// and enums as numbers
+func marshalOTLP(req *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	b, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if err := hexIDs(doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// hexIDs re-encodes the base64 ID fields anywhere in v as hex
+func hexIDs(v any) error {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			switch key {
+			case "traceId", "spanId", "parentSpanId":
+				if s, ok := val.(string); ok {
+					id, err := base64.StdEncoding.DecodeString(s)
+					if err != nil {
+						return err
+					}
+					v[key] = hex.EncodeToString(id)
+					continue
+				}
+			}
+			if err := hexIDs(val); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, val := range v {
+			if err := hexIDs(val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
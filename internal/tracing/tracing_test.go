@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOTLPFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+	tp, shutdown, err := NewTracerProvider(Config{Exporter: "otlp-file", File: path, ServiceName: "gopherso-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := tp.Tracer("test").Start(context.Background(), "work")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var spans []map[string]any
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]any `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.Unmarshal(lines.Bytes(), &req); err != nil {
+			t.Fatalf("line is not JSON: %v", err)
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+
+	// OTLP/JSON encodes IDs as hex and enums as numbers
+	want := map[string]any{
+		"name":    "work",
+		"traceId": span.SpanContext().TraceID().String(),
+		"spanId":  span.SpanContext().SpanID().String(),
+		"kind":    float64(1), // SPAN_KIND_INTERNAL
+	}
+	for key, value := range want {
+		if spans[0][key] != value {
+			t.Errorf("%s = %v, want %v", key, spans[0][key], value)
+		}
+	}
+}
+
+func TestUnknownExporter(t *testing.T) {
+	if _, _, err := NewTracerProvider(Config{Exporter: "jaeger"}); err == nil {
+		t.Error("expected an error for an unknown exporter")
+	}
+}
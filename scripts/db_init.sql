@@ -1 +0,0 @@
-CREATE DATABASE gopherso;
\ No newline at end of file